@@ -2,15 +2,20 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
 
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/scheme"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/rest"
 )
 
 var k8sListCmd = &cobra.Command{
@@ -35,6 +40,24 @@ var k8sListCmd = &cobra.Command{
 		}
 		gvr := gvrs[0]
 
+		outputFormat, _ := cmd.Flags().GetString("output")
+		if strings.EqualFold(outputFormat, "table") {
+			// Let the server render the table, so that CRD printer columns are shown
+			table, err := listTable(context.Background(), restMapper, gvr)
+			if err != nil {
+				log.Error().Err(err).Msgf("Failed to list %s", gvr.Resource)
+				os.Exit(1)
+			}
+			printFound(len(table.Rows), gvr)
+			printr := printers.NewTablePrinter(printers.PrintOptions{
+				Wide:          true,
+				WithNamespace: true,
+				ShowLabels:    false,
+			})
+			printr.PrintObj(table, os.Stdout)
+			return
+		}
+
 		resourceClient, err := makeResourceClient(k8sConfigFlags, nil, gvr, "")
 		if err != nil {
 			log.Error().Err(err).Msg("failed to create Kubernetes client")
@@ -47,14 +70,9 @@ var k8sListCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		if k8sConfigFlags.Namespace != nil && *k8sConfigFlags.Namespace != "" {
-			fmt.Printf("Found %d %s in '%s' namespace:\n", len(resources.Items), gvr.Resource, *k8sConfigFlags.Namespace)
-		} else {
-			fmt.Printf("Found %d %s in all namespaces:\n", len(resources.Items), gvr.Resource)
-		}
+		printFound(len(resources.Items), gvr)
 
 		var printr printers.ResourcePrinter
-		outputFormat, _ := cmd.Flags().GetString("output")
 		switch strings.ToLower(outputFormat) {
 		case "json":
 			printr = printers.NewTypeSetter(scheme.Scheme).ToPrinter(&printers.JSONPrinter{})
@@ -62,13 +80,6 @@ var k8sListCmd = &cobra.Command{
 			printr = printers.NewTypeSetter(scheme.Scheme).ToPrinter(&printers.YAMLPrinter{})
 		case "name":
 			printr = printers.NewTypeSetter(scheme.Scheme).ToPrinter(&printers.NamePrinter{})
-		case "table":
-			printr = printers.NewTablePrinter(printers.PrintOptions{
-				Wide:          true,
-				WithNamespace: true,
-				WithKind:      true,
-				ShowLabels:    false,
-			})
 		default:
 			log.Error().Msgf("Unknown output format: %s", outputFormat)
 			os.Exit(1)
@@ -78,6 +89,84 @@ var k8sListCmd = &cobra.Command{
 	},
 }
 
+// tableAcceptHeader asks the API server to return a list as a server-side rendered Table.
+const tableAcceptHeader = "application/json;as=Table;v=v1;g=meta.k8s.io,application/json"
+
+func printFound(count int, gvr schema.GroupVersionResource) {
+	if k8sConfigFlags.Namespace != nil && *k8sConfigFlags.Namespace != "" {
+		fmt.Printf("Found %d %s in '%s' namespace:\n", count, gvr.Resource, *k8sConfigFlags.Namespace)
+	} else {
+		fmt.Printf("Found %d %s in all namespaces:\n", count, gvr.Resource)
+	}
+}
+
+// listTable lists the resources of gvr as a Table with the columns defined by the server,
+// e.g. the additional printer columns of a CRD.
+func listTable(ctx context.Context, restMapper meta.RESTMapper, gvr schema.GroupVersionResource) (*metav1.Table, error) {
+	kubeconfigPath := viper.GetString("kubeconfig")
+	k8sConfigFlags.KubeConfig = &kubeconfigPath
+	cfg, err := k8sConfigFlags.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfg = rest.CopyConfig(cfg)
+	cfg.NegotiatedSerializer = scheme.Codecs.WithoutConversion()
+	client, err := rest.UnversionedRESTClientFor(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	gvk, err := restMapper.KindFor(gvr)
+	if err != nil {
+		return nil, err
+	}
+	mapping, err := restMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := "/apis/" + gvr.Group + "/" + gvr.Version
+	if gvr.Group == "" {
+		prefix = "/api/" + gvr.Version
+	}
+	namespace := ""
+	if k8sConfigFlags.Namespace != nil {
+		namespace = *k8sConfigFlags.Namespace
+	}
+
+	raw, err := client.Get().
+		AbsPath(prefix).
+		NamespaceIfScoped(namespace, mapping.Scope.Name() == meta.RESTScopeNameNamespace).
+		Resource(gvr.Resource).
+		Param("includeObject", string(metav1.IncludeMetadata)).
+		SetHeader("Accept", tableAcceptHeader).
+		DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var table metav1.Table
+	if err := json.Unmarshal(raw, &table); err != nil {
+		return nil, err
+	}
+	if table.Kind != "Table" {
+		return nil, fmt.Errorf("server did not return a Table for %s", gvr.Resource)
+	}
+	// The printer reads the namespace of each row from its object metadata
+	for i := range table.Rows {
+		row := &table.Rows[i]
+		if len(row.Object.Raw) == 0 {
+			continue
+		}
+		var obj metav1.PartialObjectMetadata
+		if err := json.Unmarshal(row.Object.Raw, &obj); err != nil {
+			return nil, err
+		}
+		row.Object.Object = &obj
+	}
+	return &table, nil
+}
+
 func init() {
 	k8sAPICmd.AddCommand(k8sListCmd)
 
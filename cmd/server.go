@@ -6,9 +6,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	frontendv1alpha1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1alpha1"
+	frontendv1beta1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
 	"github.com/oleksandr-san/k8s-controller/pkg/ctrl"
 	"github.com/oleksandr-san/k8s-controller/pkg/informer"
 	"github.com/rs/zerolog/log"
@@ -17,9 +20,12 @@ import (
 	"github.com/valyala/fasthttp"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/discovery/cached/memory"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/cache"
@@ -27,12 +33,24 @@ import (
 	ctrlruntime "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	metricserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 )
 
 const (
 	requestIDKey = "requestID"
 )
 
+// deprecatedControllerFlags enable a controller like adding it to --controllers does.
+var deprecatedControllerFlags = []struct {
+	name       string
+	controller string
+}{
+	{"enable-deployment-policy", "deployment"},
+	{"enable-deployment-controller", "deployment"},
+	{"enable-reloader", "reloader"},
+	{"enable-image-updates", "imageupdate"},
+}
+
 func loggingMiddleware(next fasthttp.RequestHandler) fasthttp.RequestHandler {
 	return func(ctx *fasthttp.RequestCtx) {
 		start := time.Now()
@@ -235,20 +253,48 @@ var serverCmd = &cobra.Command{
 		go multiInformer.Start(ctx)
 
 		// Start controller-runtime manager and controller
+		scheme := runtime.NewScheme()
+		utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+		utilruntime.Must(frontendv1alpha1.AddToScheme(scheme))
+		utilruntime.Must(frontendv1beta1.AddToScheme(scheme))
+
 		mgr, err := ctrlruntime.NewManager(config, manager.Options{
+			Scheme:                  scheme,
 			LeaderElection:          viper.GetBool("enable-leader-election"),
 			LeaderElectionID:        "k8s-controller-tutorial-leader-election",
 			LeaderElectionNamespace: viper.GetString("leader-election-namespace"),
 			Metrics:                 metricserver.Options{BindAddress: fmt.Sprintf(":%d", viper.GetInt("app.metrics-port"))},
+			WebhookServer: webhook.NewServer(webhook.Options{
+				Port:    viper.GetInt("webhook.port"),
+				CertDir: viper.GetString("webhook.cert-dir"),
+			}),
 		})
 		if err != nil {
 			log.Error().Err(err).Msg("Failed to create controller-runtime manager")
 			os.Exit(1)
 		}
-		if err := ctrl.AddFrontendController(mgr); err != nil {
-			log.Error().Err(err).Msg("Failed to add deployment controller")
+		selectors := viper.GetStringSlice("controllers.enabled")
+		for _, flag := range deprecatedControllerFlags {
+			if enabled, _ := cmd.Flags().GetBool(flag.name); enabled {
+				selectors = append(selectors, flag.controller)
+			}
+		}
+		controllers, err := ctrl.EnabledControllers(selectors)
+		if err != nil {
+			log.Error().Err(err).Msg("Invalid --controllers")
+			os.Exit(1)
+		}
+		log.Info().Strs("controllers", controllers).Msg("Enabling controllers")
+		if err := ctrl.SetupControllers(mgr, controllers, viper.GetViper()); err != nil {
+			log.Error().Err(err).Msg("Failed to add controllers")
 			os.Exit(1)
 		}
+		if viper.GetBool("webhook.enabled") {
+			if err := ctrl.AddFrontendWebhook(mgr); err != nil {
+				log.Error().Err(err).Msg("Failed to add frontend webhook")
+				os.Exit(1)
+			}
+		}
 		go func() {
 			log.Info().Msg("Starting controller-runtime manager...")
 			if err := mgr.Start(cmd.Context()); err != nil {
@@ -301,6 +347,56 @@ func init() {
 
 	f.Int("metrics-port", 8081, "Port for controller manager metrics")
 	viper.BindPFlag("app.metrics-port", f.Lookup("metrics-port"))
+
+	f.Bool("enable-webhooks", true, "Serve the Frontend admission and conversion webhooks, required to read and write Frontends of both versions")
+	viper.BindPFlag("webhook.enabled", f.Lookup("enable-webhooks"))
+
+	f.Int("webhook-port", 9443, "Port for the admission webhook server")
+	viper.BindPFlag("webhook.port", f.Lookup("webhook-port"))
+
+	f.String("webhook-cert-dir", "", "Directory with tls.crt and tls.key for the webhook server (default /tmp/k8s-webhook-server/serving-certs)")
+	viper.BindPFlag("webhook.cert-dir", f.Lookup("webhook-cert-dir"))
+
+	f.StringSlice("controllers", []string{"*"}, fmt.Sprintf(
+		"Controllers to run: * for the default ones, <name> to enable and -<name> to disable one, of %s",
+		strings.Join(ctrl.ControllerNames(), ", ")))
+	viper.BindPFlag("controllers.enabled", f.Lookup("controllers"))
+
+	for _, flag := range deprecatedControllerFlags {
+		f.Bool(flag.name, false, fmt.Sprintf("Enable the %s controller", flag.controller))
+		f.MarkDeprecated(flag.name, fmt.Sprintf("use --controllers=*,%s instead", flag.controller))
+	}
+
+	f.Bool("frontend-paused", false, "Stop reconciling the child objects of all Frontends, like spec.paused does for one")
+	viper.BindPFlag("controllers.frontend.paused", f.Lookup("frontend-paused"))
+
+	frontendDefaults := ctrl.DefaultFrontendOptions()
+	f.Int("frontend-max-concurrent-reconciles", frontendDefaults.MaxConcurrentReconciles, "Number of Frontends reconciled in parallel")
+	viper.BindPFlag("controllers.frontend.max-concurrent-reconciles", f.Lookup("frontend-max-concurrent-reconciles"))
+
+	f.Duration("frontend-base-delay", frontendDefaults.BaseDelay, "Initial backoff of a failing Frontend reconcile")
+	viper.BindPFlag("controllers.frontend.base-delay", f.Lookup("frontend-base-delay"))
+
+	f.Duration("frontend-max-delay", frontendDefaults.MaxDelay, "Maximum backoff of a failing Frontend reconcile")
+	viper.BindPFlag("controllers.frontend.max-delay", f.Lookup("frontend-max-delay"))
+
+	f.Float64("frontend-qps", frontendDefaults.QPS, "Overall rate of Frontend requeues per second")
+	viper.BindPFlag("controllers.frontend.qps", f.Lookup("frontend-qps"))
+
+	f.Int("frontend-burst", frontendDefaults.Burst, "Burst of Frontend requeues above the rate")
+	viper.BindPFlag("controllers.frontend.burst", f.Lookup("frontend-burst"))
+
+	f.Duration("frontend-reconcile-timeout", frontendDefaults.ReconcileTimeout, "Timeout of a Frontend reconcile, 0 to disable")
+	viper.BindPFlag("controllers.frontend.reconcile-timeout", f.Lookup("frontend-reconcile-timeout"))
+
+	f.Duration("frontend-retry-delay", frontendDefaults.RetryDelay, "Delay before retrying a Frontend reconcile after a conflict or transient error")
+	viper.BindPFlag("controllers.frontend.retry-delay", f.Lookup("frontend-retry-delay"))
+
+	f.String("deployment-policy-configmap", "default/deployment-policy", "Namespace and name of the ConfigMap holding the Deployment policy")
+	viper.BindPFlag("controllers.deployment.policy-configmap", f.Lookup("deployment-policy-configmap"))
+
+	f.Duration("image-update-interval", 5*time.Minute, "How often registries are checked for new images")
+	viper.BindPFlag("controllers.imageupdate.interval", f.Lookup("image-update-interval"))
 }
 
 func getKubeConfig(kubeconfigPath string, inCluster bool) (*rest.Config, error) {
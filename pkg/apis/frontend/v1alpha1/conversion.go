@@ -0,0 +1,286 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
+)
+
+// conversionDataAnnotation stores the fields an object had in the version it was converted
+// from that cannot be represented in the version it was converted to. The annotation is
+// removed again when converting back, so objects survive a round trip unchanged.
+const conversionDataAnnotation = "frontend.oleksandr-san.io/conversion-data"
+
+// contentsFile is the key of the v1beta1 file holding spec.contents.
+const contentsFile = "contents"
+
+// conversionData is the content of the conversion data annotation.
+type conversionData struct {
+	// Spec holds the v1beta1 fields missing in v1alpha1. Set on v1alpha1 objects.
+	Spec *v1beta1.FrontendSpec `json:"spec,omitempty"`
+	// ReplicasUnset is set on v1alpha1 objects converted from v1beta1 without replicas.
+	ReplicasUnset bool `json:"replicasUnset,omitempty"`
+	// ContentsFile is set on v1beta1 objects whose "contents" file was not spec.contents in v1alpha1.
+	ContentsFile bool `json:"contentsFile,omitempty"`
+}
+
+var _ conversion.Convertible = &Frontend{}
+
+// ConvertTo converts this Frontend to the hub version (v1beta1).
+func (src *Frontend) ConvertTo(dstRaw conversion.Hub) error {
+	dst, ok := dstRaw.(*v1beta1.Frontend)
+	if !ok {
+		return fmt.Errorf("expected a v1beta1 Frontend but got %T", dstRaw)
+	}
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	data, err := popConversionData(&dst.ObjectMeta)
+	if err != nil {
+		return err
+	}
+	dst.Spec = specToHub(&src.Spec, data)
+	dst.Status = statusToHub(&src.Status)
+
+	if file, ok := src.Spec.Files[contentsFile]; ok && src.Spec.Contents == "" && isContentsFile(file.Path, file.Content, file.BinaryContent) {
+		return pushConversionData(&dst.ObjectMeta, conversionData{ContentsFile: true})
+	}
+	return nil
+}
+
+// ConvertFrom converts a Frontend from the hub version (v1beta1) to this version.
+func (dst *Frontend) ConvertFrom(srcRaw conversion.Hub) error {
+	src, ok := srcRaw.(*v1beta1.Frontend)
+	if !ok {
+		return fmt.Errorf("expected a v1beta1 Frontend but got %T", srcRaw)
+	}
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	data, err := popConversionData(&dst.ObjectMeta)
+	if err != nil {
+		return err
+	}
+	dst.Spec = specFromHub(&src.Spec, data)
+	dst.Status = statusFromHub(&src.Status)
+
+	var lost conversionData
+	if hubOnly := hubOnlySpec(&src.Spec); !equality.Semantic.DeepEqual(hubOnly, v1beta1.FrontendSpec{}) {
+		lost.Spec = &hubOnly
+	}
+	lost.ReplicasUnset = src.Spec.Replicas == nil
+	if lost != (conversionData{}) {
+		return pushConversionData(&dst.ObjectMeta, lost)
+	}
+	return nil
+}
+
+// hubOnlySpec returns the fields of spec that v1alpha1 cannot represent.
+func hubOnlySpec(spec *v1beta1.FrontendSpec) v1beta1.FrontendSpec {
+	return v1beta1.FrontendSpec{
+		Autoscaling:          spec.Autoscaling,
+		ContainerPort:        spec.ContainerPort,
+		DeletionPolicy:       spec.DeletionPolicy,
+		DisruptionBudget:     spec.DisruptionBudget,
+		NetworkPolicy:        spec.NetworkPolicy,
+		Paused:               spec.Paused,
+		PodTemplate:          spec.PodTemplate,
+		RevisionHistoryLimit: spec.RevisionHistoryLimit,
+		RollbackTo:           spec.RollbackTo,
+		RolloutStrategy:      spec.RolloutStrategy,
+		Suspend:              spec.Suspend,
+	}
+}
+
+// restoreHubOnlySpec copies the fields returned by hubOnlySpec from src to dst.
+func restoreHubOnlySpec(dst, src *v1beta1.FrontendSpec) {
+	dst.Autoscaling = src.Autoscaling
+	dst.ContainerPort = src.ContainerPort
+	dst.DeletionPolicy = src.DeletionPolicy
+	dst.DisruptionBudget = src.DisruptionBudget
+	dst.NetworkPolicy = src.NetworkPolicy
+	dst.Paused = src.Paused
+	dst.PodTemplate = src.PodTemplate
+	dst.RevisionHistoryLimit = src.RevisionHistoryLimit
+	dst.RollbackTo = src.RollbackTo
+	dst.RolloutStrategy = src.RolloutStrategy
+	dst.Suspend = src.Suspend
+}
+
+// specToHub converts the spec to v1beta1, which stores spec.contents as the "contents" file.
+// Replicas beyond the int32 range are rejected by the schema of both versions.
+func specToHub(in *FrontendSpec, data *conversionData) v1beta1.FrontendSpec {
+	in = in.DeepCopy()
+	replicas := int32(in.Replicas)
+	out := v1beta1.FrontendSpec{
+		Image:               in.Image,
+		Replicas:            &replicas,
+		MountPath:           in.MountPath,
+		ContentUpdatePolicy: v1beta1.ContentUpdatePolicy(in.ContentUpdatePolicy),
+		Service:             (*v1beta1.ServiceSpec)(in.Service),
+		Ingress:             (*v1beta1.IngressSpec)(in.Ingress),
+	}
+
+	if in.Files != nil {
+		out.Files = make(map[string]v1beta1.FrontendFile, len(in.Files))
+		for key, file := range in.Files {
+			out.Files[key] = v1beta1.FrontendFile(file)
+		}
+	}
+	// A file with the same key takes precedence over spec.contents, as it did in v1alpha1
+	if _, ok := out.Files[contentsFile]; in.Contents != "" && !ok {
+		if out.Files == nil {
+			out.Files = map[string]v1beta1.FrontendFile{}
+		}
+		out.Files[contentsFile] = v1beta1.FrontendFile{Content: in.Contents}
+	}
+
+	if in.ContentsFrom != nil {
+		out.ContentsFrom = make([]v1beta1.ContentSource, len(in.ContentsFrom))
+		for i, src := range in.ContentsFrom {
+			out.ContentsFrom[i] = v1beta1.ContentSource{
+				Path:            src.Path,
+				ConfigMapKeyRef: src.ConfigMapKeyRef,
+				SecretKeyRef:    src.SecretKeyRef,
+				FrontendRef:     (*v1beta1.FrontendKeySelector)(src.FrontendRef),
+			}
+		}
+	}
+
+	if data != nil {
+		if data.Spec != nil {
+			restoreHubOnlySpec(&out, data.Spec)
+		}
+		// Unless the replicas were changed through v1alpha1
+		if data.ReplicasUnset && in.Replicas == 1 {
+			out.Replicas = nil
+		}
+	}
+	return out
+}
+
+// specFromHub converts a v1beta1 spec, moving a plain "contents" file back into spec.contents.
+func specFromHub(in *v1beta1.FrontendSpec, data *conversionData) FrontendSpec {
+	in = in.DeepCopy()
+	out := FrontendSpec{
+		Image:               in.Image,
+		Replicas:            1,
+		MountPath:           in.MountPath,
+		ContentUpdatePolicy: ContentUpdatePolicy(in.ContentUpdatePolicy),
+		Service:             (*ServiceSpec)(in.Service),
+		Ingress:             (*IngressSpec)(in.Ingress),
+	}
+	if in.Replicas != nil {
+		out.Replicas = int(*in.Replicas)
+	}
+
+	if in.Files != nil {
+		out.Files = make(map[string]FrontendFile, len(in.Files))
+		for key, file := range in.Files {
+			out.Files[key] = FrontendFile(file)
+		}
+	}
+	file, ok := out.Files[contentsFile]
+	if ok && isContentsFile(file.Path, file.Content, file.BinaryContent) && (data == nil || !data.ContentsFile) {
+		out.Contents = file.Content
+		delete(out.Files, contentsFile)
+		if len(out.Files) == 0 {
+			out.Files = nil
+		}
+	}
+
+	if in.ContentsFrom != nil {
+		out.ContentsFrom = make([]ContentSource, len(in.ContentsFrom))
+		for i, src := range in.ContentsFrom {
+			out.ContentsFrom[i] = ContentSource{
+				Path:            src.Path,
+				ConfigMapKeyRef: src.ConfigMapKeyRef,
+				SecretKeyRef:    src.SecretKeyRef,
+				FrontendRef:     (*FrontendKeySelector)(src.FrontendRef),
+			}
+		}
+	}
+	return out
+}
+
+func statusToHub(in *FrontendStatus) v1beta1.FrontendStatus {
+	in = in.DeepCopy()
+	out := v1beta1.FrontendStatus{
+		ObservedGeneration: in.ObservedGeneration,
+		Replicas:           in.Replicas,
+		ReadyReplicas:      in.ReadyReplicas,
+		Selector:           in.Selector,
+		ContentRevision:    in.ContentRevision,
+		Conditions:         in.Conditions,
+	}
+	if in.Rollout != nil {
+		out.Rollout = &v1beta1.RolloutStatus{
+			Phase:          v1beta1.RolloutPhase(in.Rollout.Phase),
+			StableRevision: in.Rollout.StableRevision,
+			CanaryRevision: in.Rollout.CanaryRevision,
+			Step:           in.Rollout.Step,
+			StepStartTime:  in.Rollout.StepStartTime,
+		}
+	}
+	return out
+}
+
+func statusFromHub(in *v1beta1.FrontendStatus) FrontendStatus {
+	in = in.DeepCopy()
+	out := FrontendStatus{
+		ObservedGeneration: in.ObservedGeneration,
+		Replicas:           in.Replicas,
+		ReadyReplicas:      in.ReadyReplicas,
+		Selector:           in.Selector,
+		ContentRevision:    in.ContentRevision,
+		Conditions:         in.Conditions,
+	}
+	if in.Rollout != nil {
+		out.Rollout = &RolloutStatus{
+			Phase:          RolloutPhase(in.Rollout.Phase),
+			StableRevision: in.Rollout.StableRevision,
+			CanaryRevision: in.Rollout.CanaryRevision,
+			Step:           in.Rollout.Step,
+			StepStartTime:  in.Rollout.StepStartTime,
+		}
+	}
+	return out
+}
+
+// isContentsFile reports whether a "contents" file can be represented as spec.contents.
+func isContentsFile(path, content string, binaryContent []byte) bool {
+	return path == "" && content != "" && len(binaryContent) == 0
+}
+
+func pushConversionData(meta *metav1.ObjectMeta, data conversionData) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if meta.Annotations == nil {
+		meta.Annotations = map[string]string{}
+	}
+	meta.Annotations[conversionDataAnnotation] = string(raw)
+	return nil
+}
+
+// popConversionData removes the conversion data annotation from meta and returns its content.
+func popConversionData(meta *metav1.ObjectMeta) (*conversionData, error) {
+	raw, ok := meta.Annotations[conversionDataAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	delete(meta.Annotations, conversionDataAnnotation)
+	if len(meta.Annotations) == 0 {
+		meta.Annotations = nil
+	}
+
+	var data conversionData
+	if err := json.Unmarshal([]byte(raw), &data); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", conversionDataAnnotation, err)
+	}
+	return &data, nil
+}
@@ -0,0 +1,128 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/randfill"
+
+	"github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
+)
+
+const fuzzIterations = 1000
+
+func newFiller() *randfill.Filler {
+	return randfill.New().NilChance(0.3).NumElements(0, 3).Funcs(
+		// Managed fields and timestamps are irrelevant for the conversion and do not fill well
+		func(meta *metav1.ObjectMeta, c randfill.Continue) {
+			c.FillNoCustom(meta)
+			meta.ManagedFields = nil
+			meta.CreationTimestamp = metav1.Time{}
+			meta.DeletionTimestamp = nil
+		},
+		func(cond *metav1.Condition, c randfill.Continue) {
+			c.FillNoCustom(cond)
+			cond.LastTransitionTime = metav1.Time{}
+		},
+		// Files holding spec.contents are common, make sure they are covered
+		func(file *FrontendFile, c randfill.Continue) {
+			c.FillNoCustom(file)
+			if c.Bool() {
+				file.Path, file.BinaryContent = "", nil
+			}
+		},
+		// Replicas beyond int32 and a "contents" file next to spec.contents are rejected on admission
+		func(spec *FrontendSpec, c randfill.Continue) {
+			c.FillNoCustom(spec)
+			spec.Replicas = int(c.Int31())
+			if c.Bool() && spec.Files != nil {
+				spec.Files[contentsFile] = FrontendFile{Content: c.String(0)}
+			}
+			if spec.Contents != "" {
+				delete(spec.Files, contentsFile)
+			}
+		},
+		func(file *v1beta1.FrontendFile, c randfill.Continue) {
+			c.FillNoCustom(file)
+			if c.Bool() {
+				file.Path, file.BinaryContent = "", nil
+			}
+		},
+		func(spec *v1beta1.FrontendSpec, c randfill.Continue) {
+			c.FillNoCustom(spec)
+			if c.Bool() && spec.Files != nil {
+				spec.Files[contentsFile] = v1beta1.FrontendFile{Content: c.String(0)}
+			}
+		},
+	)
+}
+
+func TestConversion_SpokeRoundTrip(t *testing.T) {
+	f := newFiller()
+	for i := 0; i < fuzzIterations; i++ {
+		var original Frontend
+		f.Fill(&original)
+
+		var hub v1beta1.Frontend
+		require.NoError(t, original.DeepCopy().ConvertTo(&hub))
+		var got Frontend
+		require.NoError(t, got.ConvertFrom(&hub))
+
+		got.TypeMeta = original.TypeMeta
+		require.True(t, equality.Semantic.DeepEqual(original, got),
+			"round trip through v1beta1 changed the object:\n%s", diff(original, got))
+	}
+}
+
+func TestConversion_HubRoundTrip(t *testing.T) {
+	f := newFiller()
+	for i := 0; i < fuzzIterations; i++ {
+		var original v1beta1.Frontend
+		f.Fill(&original)
+
+		var spoke Frontend
+		require.NoError(t, spoke.ConvertFrom(original.DeepCopy()))
+		var got v1beta1.Frontend
+		require.NoError(t, spoke.ConvertTo(&got))
+
+		got.TypeMeta = original.TypeMeta
+		require.True(t, equality.Semantic.DeepEqual(original, got),
+			"round trip through v1alpha1 changed the object:\n%s", diff(original, got))
+	}
+}
+
+func TestConversion_Contents(t *testing.T) {
+	page := &Frontend{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-page", Namespace: "default"},
+		Spec:       FrontendSpec{Contents: "hello world", Replicas: 2},
+	}
+
+	var hub v1beta1.Frontend
+	require.NoError(t, page.ConvertTo(&hub))
+	require.Equal(t, map[string]v1beta1.FrontendFile{"contents": {Content: "hello world"}}, hub.Spec.Files)
+	require.Equal(t, int32(2), *hub.Spec.Replicas)
+	require.Empty(t, hub.Annotations, "lossless conversions must not annotate the object")
+
+	// Fields missing in v1alpha1 survive updates made through it
+	hub.Spec.Replicas = nil
+	hub.Spec.DeletionPolicy = v1beta1.DeletionOrphan
+	var spoke Frontend
+	require.NoError(t, spoke.ConvertFrom(&hub))
+	require.Equal(t, 1, spoke.Spec.Replicas)
+	require.Contains(t, spoke.Annotations, conversionDataAnnotation)
+
+	spoke.Spec.Replicas = 3
+	var updated v1beta1.Frontend
+	require.NoError(t, spoke.ConvertTo(&updated))
+	require.Equal(t, int32(3), *updated.Spec.Replicas)
+	require.Equal(t, v1beta1.DeletionOrphan, updated.Spec.DeletionPolicy)
+	require.Empty(t, updated.Annotations)
+}
+
+func diff(a, b any) string {
+	return cmp.Diff(a, b, cmpopts.EquateEmpty())
+}
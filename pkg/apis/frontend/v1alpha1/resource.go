@@ -1,22 +1,201 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// Condition types reported in FrontendStatus.Conditions
+const (
+	// ConditionReady is True when the Deployment serves the current content with all desired replicas ready.
+	ConditionReady = "Ready"
+	// ConditionProgressing is True while the Deployment is rolling out a new revision.
+	ConditionProgressing = "Progressing"
+	// ConditionDegraded is True when the controller failed to reconcile or the Deployment cannot make progress.
+	ConditionDegraded = "Degraded"
+	// ConditionPaused is True while the controller leaves the child objects untouched.
+	ConditionPaused = "Paused"
+)
+
+// ContentUpdatePolicy describes how running pods pick up content changes.
+// +kubebuilder:validation:Enum=Rollout;InPlace
+type ContentUpdatePolicy string
+
+const (
+	// ContentUpdateRollout triggers a rolling update of the Deployment whenever the content changes.
+	ContentUpdateRollout ContentUpdatePolicy = "Rollout"
+	// ContentUpdateInPlace only updates the ConfigMap and relies on the kubelet to refresh the mounted volume.
+	ContentUpdateInPlace ContentUpdatePolicy = "InPlace"
+)
+
+// ServiceSpec configures the Service exposing the Frontend pods.
+type ServiceSpec struct {
+	// Type of the Service. Defaults to ClusterIP.
+	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
+	// +optional
+	Type corev1.ServiceType `json:"type,omitempty"`
+	// Port the Service listens on. Defaults to 80.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port int32 `json:"port,omitempty"`
+	// Annotations added to the Service, e.g. for cloud load balancer settings.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// IngressSpec configures an Ingress routing external traffic to the Frontend Service.
+type IngressSpec struct {
+	// Host the Ingress rule matches.
+	// +kubebuilder:validation:MinLength=1
+	Host string `json:"host"`
+	// Path prefix routed to the Frontend. Defaults to "/".
+	// +kubebuilder:validation:Pattern=`^/`
+	// +optional
+	Path string `json:"path,omitempty"`
+	// IngressClassName selects the Ingress controller.
+	// +optional
+	IngressClassName *string `json:"ingressClassName,omitempty"`
+	// TLSSecretName is the Secret holding the TLS certificate for Host. TLS is disabled if empty.
+	// +optional
+	TLSSecretName string `json:"tlsSecretName,omitempty"`
+}
+
+// FrontendFile is a single file served by the Frontend.
+type FrontendFile struct {
+	// Path of the file relative to the mount path. Defaults to the key of the file.
+	// +optional
+	Path string `json:"path,omitempty"`
+	// Content of a text file.
+	// +optional
+	Content string `json:"content,omitempty"`
+	// BinaryContent of a binary file, base64-encoded in YAML and JSON.
+	// +optional
+	BinaryContent []byte `json:"binaryContent,omitempty"`
+}
+
+// FrontendKeySelector selects a file of another Frontend.
+type FrontendKeySelector struct {
+	// Name of the Frontend in the same namespace.
+	Name string `json:"name"`
+	// Key of the file in the referenced Frontend.
+	Key string `json:"key"`
+}
+
+// ContentSource references a file stored outside of the Frontend spec.
+// Exactly one of ConfigMapKeyRef, SecretKeyRef and FrontendRef must be set.
+type ContentSource struct {
+	// Path of the file relative to the mount path. Defaults to the key of the source.
+	// +optional
+	Path string `json:"path,omitempty"`
+	// ConfigMapKeyRef selects a key of a ConfigMap in the same namespace.
+	// +optional
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+	// SecretKeyRef selects a key of a Secret in the same namespace.
+	// +optional
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+	// FrontendRef selects a file of another Frontend in the same namespace.
+	// +optional
+	FrontendRef *FrontendKeySelector `json:"frontendRef,omitempty"`
+}
+
 // FrontendSpec defines the desired state of Frontend
 type FrontendSpec struct {
-	Contents string `json:"contents"`
-	Image    string `json:"image"`
-	Replicas int    `json:"replicas"`
+	// Contents is served as a single file named "contents" in the mount path.
+	// +optional
+	Contents string `json:"contents,omitempty"`
+	// Image serving the files. Defaulted by the admission webhook if empty.
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	Image string `json:"image,omitempty"`
+	// Replicas is the desired number of pods.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=2147483647
+	// +kubebuilder:default=1
+	// +optional
+	Replicas int `json:"replicas"`
+
+	// Files served by the Frontend, keyed by a valid ConfigMap key.
+	// +optional
+	Files map[string]FrontendFile `json:"files,omitempty"`
+	// ContentsFrom adds files from existing ConfigMaps, Secrets or Frontends.
+	// Changes to the referenced objects are picked up automatically.
+	// +optional
+	ContentsFrom []ContentSource `json:"contentsFrom,omitempty"`
+	// MountPath is the directory the files are mounted into. Defaults to /data.
+	// +kubebuilder:validation:Pattern=`^/`
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
+
+	// ContentUpdatePolicy controls whether content changes roll the pods. Defaults to Rollout.
+	// +kubebuilder:default=Rollout
+	// +optional
+	ContentUpdatePolicy ContentUpdatePolicy `json:"contentUpdatePolicy,omitempty"`
+
+	// Service configures the Service created for the Frontend.
+	// +optional
+	Service *ServiceSpec `json:"service,omitempty"`
+	// Ingress exposes the Frontend Service outside the cluster. No Ingress is created if unset.
+	// +optional
+	Ingress *IngressSpec `json:"ingress,omitempty"`
+}
+
+// RolloutPhase is the phase of a content rollout: Stable, Progressing, Paused, Promoting or Aborted.
+type RolloutPhase string
+
+// RolloutStatus is the state of a BlueGreen or Canary content rollout.
+type RolloutStatus struct {
+	// Phase of the rollout.
+	Phase RolloutPhase `json:"phase,omitempty"`
+	// StableRevision is the content revision of the stable Deployment.
+	StableRevision string `json:"stableRevision,omitempty"`
+	// CanaryRevision is the content revision being rolled out, or the last aborted one.
+	CanaryRevision string `json:"canaryRevision,omitempty"`
+	// Step is the index of the current canary step.
+	Step int32 `json:"step,omitempty"`
+	// StepStartTime is when the pods of the current step became ready.
+	StepStartTime *metav1.Time `json:"stepStartTime,omitempty"`
+}
+
+// FrontendStatus defines the observed state of Frontend
+type FrontendStatus struct {
+	// ObservedGeneration is the last Frontend generation processed by the controller.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Replicas is the number of pods of the owned Deployment.
+	Replicas int32 `json:"replicas,omitempty"`
+	// ReadyReplicas is the number of ready pods of the owned Deployment.
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+	// Selector is the label selector of the Frontend pods, used by the scale subresource.
+	Selector string `json:"selector,omitempty"`
+	// ContentRevision identifies the content currently rendered into the ConfigMap.
+	ContentRevision string `json:"contentRevision,omitempty"`
+	// Rollout is the state of the content rollout. Only set for the BlueGreen and Canary strategies.
+	// +optional
+	Rollout *RolloutStatus `json:"rollout,omitempty"`
+
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.selector
+// +kubebuilder:printcolumn:name="Image",type=string,JSONPath=`.spec.image`
+// +kubebuilder:printcolumn:name="Desired",type=integer,JSONPath=`.spec.replicas`
+// +kubebuilder:printcolumn:name="Ready",type=integer,JSONPath=`.status.readyReplicas`
+// +kubebuilder:printcolumn:name="Revision",type=string,JSONPath=`.status.contentRevision`
+// +kubebuilder:printcolumn:name="Rollout",type=string,JSONPath=`.status.rollout.phase`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+// +kubebuilder:validation:XValidation:rule="self.metadata.name.matches('^[a-z]([-a-z0-9]*[a-z0-9])?$') && size(self.metadata.name) <= 63",message="name must be a DNS-1035 label, as it is used for the Service"
 type Frontend struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec FrontendSpec `json:"spec"`
+	Spec   FrontendSpec   `json:"spec"`
+	Status FrontendStatus `json:"status,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -0,0 +1,4 @@
+package v1beta1
+
+// Hub marks v1beta1 as the version all other Frontend versions are converted through.
+func (*Frontend) Hub() {}
@@ -0,0 +1,433 @@
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Condition types reported in FrontendStatus.Conditions
+const (
+	// ConditionReady is True when the Deployment serves the current content with all desired replicas ready.
+	ConditionReady = "Ready"
+	// ConditionProgressing is True while the Deployment is rolling out a new revision.
+	ConditionProgressing = "Progressing"
+	// ConditionDegraded is True when the controller failed to reconcile or the Deployment cannot make progress.
+	ConditionDegraded = "Degraded"
+	// ConditionPaused is True while the controller leaves the child objects untouched.
+	ConditionPaused = "Paused"
+)
+
+// ContentUpdatePolicy describes how running pods pick up content changes.
+// +kubebuilder:validation:Enum=Rollout;InPlace
+type ContentUpdatePolicy string
+
+const (
+	// ContentUpdateRollout triggers a rolling update of the Deployment whenever the content changes.
+	ContentUpdateRollout ContentUpdatePolicy = "Rollout"
+	// ContentUpdateInPlace only updates the ConfigMap and relies on the kubelet to refresh the mounted volume.
+	ContentUpdateInPlace ContentUpdatePolicy = "InPlace"
+)
+
+// DeletionPolicy describes what happens to the objects owned by a Frontend when it is deleted.
+// +kubebuilder:validation:Enum=Delete;Orphan;Retain
+type DeletionPolicy string
+
+const (
+	// DeletionDelete deletes all owned objects together with the Frontend.
+	DeletionDelete DeletionPolicy = "Delete"
+	// DeletionOrphan keeps all owned objects running and releases them from the Frontend,
+	// so that they can be taken over without downtime.
+	DeletionOrphan DeletionPolicy = "Orphan"
+	// DeletionRetain deletes the Deployment and networking objects but keeps the content ConfigMap.
+	DeletionRetain DeletionPolicy = "Retain"
+)
+
+// RolloutStrategyType describes how content changes are rolled out.
+// +kubebuilder:validation:Enum=RollingUpdate;BlueGreen;Canary
+type RolloutStrategyType string
+
+const (
+	// RolloutRollingUpdate rolls content changes out to the pods of the Frontend Deployment.
+	RolloutRollingUpdate RolloutStrategyType = "RollingUpdate"
+	// RolloutBlueGreen starts the new content in a second Deployment and switches the Service
+	// to it once it is ready.
+	RolloutBlueGreen RolloutStrategyType = "BlueGreen"
+	// RolloutCanary starts the new content in a second Deployment and shifts the replicas to it step by step.
+	RolloutCanary RolloutStrategyType = "Canary"
+)
+
+// RolloutPhase is the phase of a content rollout.
+type RolloutPhase string
+
+const (
+	// RolloutPhaseStable means no rollout is in progress.
+	RolloutPhaseStable RolloutPhase = "Stable"
+	// RolloutPhaseProgressing means the new revision is starting or a canary step is running.
+	RolloutPhaseProgressing RolloutPhase = "Progressing"
+	// RolloutPhasePaused means the rollout waits for the promote action.
+	RolloutPhasePaused RolloutPhase = "Paused"
+	// RolloutPhasePromoting means the new revision is rolled out to the stable Deployment.
+	RolloutPhasePromoting RolloutPhase = "Promoting"
+	// RolloutPhaseAborted means the rollout was aborted; the stable revision keeps serving
+	// until the content changes again.
+	RolloutPhaseAborted RolloutPhase = "Aborted"
+)
+
+// BlueGreenStrategy configures the BlueGreen rollout strategy.
+type BlueGreenStrategy struct {
+	// ManualPromotion keeps the traffic on the stable revision until the promote action is
+	// requested, instead of switching as soon as the new revision is ready.
+	// +optional
+	ManualPromotion bool `json:"manualPromotion,omitempty"`
+}
+
+// CanaryStrategy configures the Canary rollout strategy.
+type CanaryStrategy struct {
+	// Steps are the percentages of the replicas running the new revision, in increasing order.
+	// The new revision is promoted after the last step.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:items:Minimum=1
+	// +kubebuilder:validation:items:Maximum=99
+	Steps []int32 `json:"steps"`
+	// StepDuration is how long a step runs once its pods are ready. Each step waits for the
+	// promote action if unset.
+	// +optional
+	StepDuration *metav1.Duration `json:"stepDuration,omitempty"`
+}
+
+// RolloutStrategy configures how content changes are rolled out.
+// The promote and abort actions are requested with the frontend.oleksandr-san.io/rollout-action annotation.
+// +kubebuilder:validation:XValidation:rule="self.type != 'Canary' || has(self.canary)",message="canary is required for the Canary strategy"
+type RolloutStrategy struct {
+	// Type of the strategy. Defaults to RollingUpdate.
+	// +kubebuilder:default=RollingUpdate
+	// +optional
+	Type RolloutStrategyType `json:"type,omitempty"`
+	// BlueGreen configures the BlueGreen strategy.
+	// +optional
+	BlueGreen *BlueGreenStrategy `json:"blueGreen,omitempty"`
+	// Canary configures the Canary strategy.
+	// +optional
+	Canary *CanaryStrategy `json:"canary,omitempty"`
+}
+
+// ServiceSpec configures the Service exposing the Frontend pods.
+type ServiceSpec struct {
+	// Type of the Service. Defaults to ClusterIP.
+	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
+	// +optional
+	Type corev1.ServiceType `json:"type,omitempty"`
+	// Port the Service listens on. Defaults to 80.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port int32 `json:"port,omitempty"`
+	// Annotations added to the Service, e.g. for cloud load balancer settings.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// IngressSpec configures an Ingress routing external traffic to the Frontend Service.
+type IngressSpec struct {
+	// Host the Ingress rule matches.
+	// +kubebuilder:validation:MinLength=1
+	Host string `json:"host"`
+	// Path prefix routed to the Frontend. Defaults to "/".
+	// +kubebuilder:validation:Pattern=`^/`
+	// +optional
+	Path string `json:"path,omitempty"`
+	// IngressClassName selects the Ingress controller.
+	// +optional
+	IngressClassName *string `json:"ingressClassName,omitempty"`
+	// TLSSecretName is the Secret holding the TLS certificate for Host. TLS is disabled if empty.
+	// +optional
+	TLSSecretName string `json:"tlsSecretName,omitempty"`
+}
+
+// AutoscalingSpec configures a HorizontalPodAutoscaler scaling the Frontend Deployment.
+// Utilization targets are relative to the resource requests of the Frontend container.
+// +kubebuilder:validation:XValidation:rule="!has(self.minReplicas) || self.minReplicas <= self.maxReplicas",message="maxReplicas must be greater than or equal to minReplicas"
+type AutoscalingSpec struct {
+	// MinReplicas is the lower limit for the number of pods. Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=1
+	// +optional
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+	// MaxReplicas is the upper limit for the number of pods.
+	// +kubebuilder:validation:Minimum=1
+	MaxReplicas int32 `json:"maxReplicas"`
+	// TargetCPUUtilizationPercentage is the average CPU utilization to scale to.
+	// Defaults to 80 if no target is set.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TargetCPUUtilizationPercentage *int32 `json:"targetCPUUtilizationPercentage,omitempty"`
+	// TargetMemoryUtilizationPercentage is the average memory utilization to scale to.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TargetMemoryUtilizationPercentage *int32 `json:"targetMemoryUtilizationPercentage,omitempty"`
+}
+
+// DisruptionBudgetSpec configures a PodDisruptionBudget limiting voluntary disruptions of the
+// Frontend pods, such as node drains.
+// +kubebuilder:validation:XValidation:rule="!(has(self.minAvailable) && has(self.maxUnavailable))",message="minAvailable and maxUnavailable are mutually exclusive"
+type DisruptionBudgetSpec struct {
+	// MinAvailable is the number or percentage of pods that must stay available.
+	// +optional
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+	// MaxUnavailable is the number or percentage of pods that may be unavailable.
+	// Defaults to 1 if minAvailable is not set.
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// NetworkPolicySpec configures a NetworkPolicy isolating the Frontend pods. Traffic that is
+// not allowed explicitly is denied.
+type NetworkPolicySpec struct {
+	// From lists the sources allowed to connect to the HTTP port of the pods. Any source may
+	// connect to the HTTP port if empty.
+	// +optional
+	From []networkingv1.NetworkPolicyPeer `json:"from,omitempty"`
+	// Egress lists the outgoing traffic allowed from the pods. All outgoing traffic is denied if empty.
+	// +optional
+	Egress []networkingv1.NetworkPolicyEgressRule `json:"egress,omitempty"`
+}
+
+// PodTemplateSpec customizes the pods of the Frontend Deployment. The fields are merged into
+// the generated pod template; the container runs as non-root with a read-only root filesystem
+// unless SecurityContext and PodSecurityContext say otherwise.
+type PodTemplateSpec struct {
+	// Labels added to the pods. The "app" label is reserved for the pod selector.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// Annotations added to the pods.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Resources of the Frontend container.
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	// LivenessProbe of the Frontend container.
+	// +optional
+	LivenessProbe *corev1.Probe `json:"livenessProbe,omitempty"`
+	// ReadinessProbe of the Frontend container.
+	// +optional
+	ReadinessProbe *corev1.Probe `json:"readinessProbe,omitempty"`
+	// Env sets environment variables of the Frontend container.
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+	// SecurityContext of the Frontend container. Unset fields keep their secure defaults.
+	// +optional
+	SecurityContext *corev1.SecurityContext `json:"securityContext,omitempty"`
+
+	// PodSecurityContext of the pods. Unset fields keep their secure defaults.
+	// +optional
+	PodSecurityContext *corev1.PodSecurityContext `json:"podSecurityContext,omitempty"`
+	// ImagePullSecrets used to pull the image.
+	// +optional
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+	// NodeSelector constrains the nodes the pods are scheduled on.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Affinity of the pods.
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+	// Tolerations of the pods.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+}
+
+// FrontendFile is a single file served by the Frontend.
+type FrontendFile struct {
+	// Path of the file relative to the mount path. Defaults to the key of the file.
+	// +optional
+	Path string `json:"path,omitempty"`
+	// Content of a text file.
+	// +optional
+	Content string `json:"content,omitempty"`
+	// BinaryContent of a binary file, base64-encoded in YAML and JSON.
+	// +optional
+	BinaryContent []byte `json:"binaryContent,omitempty"`
+}
+
+// FrontendKeySelector selects a file of another Frontend.
+type FrontendKeySelector struct {
+	// Name of the Frontend in the same namespace.
+	Name string `json:"name"`
+	// Key of the file in the referenced Frontend.
+	Key string `json:"key"`
+}
+
+// ContentSource references a file stored outside of the Frontend spec.
+// Exactly one of ConfigMapKeyRef, SecretKeyRef and FrontendRef must be set.
+type ContentSource struct {
+	// Path of the file relative to the mount path. Defaults to the key of the source.
+	// +optional
+	Path string `json:"path,omitempty"`
+	// ConfigMapKeyRef selects a key of a ConfigMap in the same namespace.
+	// +optional
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+	// SecretKeyRef selects a key of a Secret in the same namespace.
+	// +optional
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+	// FrontendRef selects a file of another Frontend in the same namespace.
+	// +optional
+	FrontendRef *FrontendKeySelector `json:"frontendRef,omitempty"`
+}
+
+// FrontendSpec defines the desired state of Frontend
+type FrontendSpec struct {
+	// Image serving the files. Defaulted by the admission webhook if empty.
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	Image string `json:"image,omitempty"`
+	// ContainerPort is the port the image serves HTTP on. Defaults to 8080 for the default
+	// image, which runs as non-root, and to 80 for other images.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	ContainerPort int32 `json:"containerPort,omitempty"`
+	// Replicas is the desired number of pods. Defaults to 1. Ignored while autoscaling is enabled.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=1
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+	// Autoscaling creates a HorizontalPodAutoscaler that manages the number of pods instead of
+	// spec.replicas. Autoscaling is disabled if unset.
+	// +optional
+	Autoscaling *AutoscalingSpec `json:"autoscaling,omitempty"`
+
+	// Files served by the Frontend, keyed by a valid ConfigMap key.
+	// +optional
+	Files map[string]FrontendFile `json:"files,omitempty"`
+	// ContentsFrom adds files from existing ConfigMaps, Secrets or Frontends.
+	// Changes to the referenced objects are picked up automatically.
+	// +optional
+	ContentsFrom []ContentSource `json:"contentsFrom,omitempty"`
+	// MountPath is the directory the files are mounted into. Defaults to /data.
+	// +kubebuilder:validation:Pattern=`^/`
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
+
+	// ContentUpdatePolicy controls whether content changes roll the pods. Defaults to Rollout.
+	// +kubebuilder:default=Rollout
+	// +optional
+	ContentUpdatePolicy ContentUpdatePolicy `json:"contentUpdatePolicy,omitempty"`
+	// RevisionHistoryLimit is the number of old content revisions to retain for rollbacks.
+	// Defaults to 10.
+	// +kubebuilder:default=10
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
+	// RollbackTo serves a retained content revision instead of the content of the spec.
+	// Remove it to serve the content of the spec again.
+	// +kubebuilder:validation:Pattern=`^[0-9a-f]{16}$`
+	// +optional
+	RollbackTo string `json:"rollbackTo,omitempty"`
+
+	// RolloutStrategy controls how content changes reach the pods. Other changes are applied
+	// to all pods directly.
+	// +optional
+	RolloutStrategy *RolloutStrategy `json:"rolloutStrategy,omitempty"`
+
+	// DeletionPolicy controls what happens to the owned objects when the Frontend is deleted. Defaults to Delete.
+	// +kubebuilder:default=Delete
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// Paused stops the controller from changing the child objects, which can then be edited by
+	// hand. The status is still updated.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+	// Suspend scales the Frontend down to zero pods while keeping its child objects.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// PodTemplate customizes the pods of the Frontend Deployment.
+	// +optional
+	PodTemplate *PodTemplateSpec `json:"podTemplate,omitempty"`
+
+	// Service configures the Service created for the Frontend.
+	// +optional
+	Service *ServiceSpec `json:"service,omitempty"`
+	// Ingress exposes the Frontend Service outside the cluster. No Ingress is created if unset.
+	// +optional
+	Ingress *IngressSpec `json:"ingress,omitempty"`
+	// DisruptionBudget creates a PodDisruptionBudget for the Frontend pods. None is created if unset.
+	// +optional
+	DisruptionBudget *DisruptionBudgetSpec `json:"disruptionBudget,omitempty"`
+	// NetworkPolicy creates a NetworkPolicy for the Frontend pods. None is created if unset.
+	// +optional
+	NetworkPolicy *NetworkPolicySpec `json:"networkPolicy,omitempty"`
+}
+
+// RolloutStatus is the state of a BlueGreen or Canary content rollout.
+type RolloutStatus struct {
+	// Phase of the rollout.
+	Phase RolloutPhase `json:"phase,omitempty"`
+	// StableRevision is the content revision of the stable Deployment.
+	StableRevision string `json:"stableRevision,omitempty"`
+	// CanaryRevision is the content revision being rolled out, or the last aborted one.
+	CanaryRevision string `json:"canaryRevision,omitempty"`
+	// Step is the index of the current canary step.
+	Step int32 `json:"step,omitempty"`
+	// StepStartTime is when the pods of the current step became ready.
+	StepStartTime *metav1.Time `json:"stepStartTime,omitempty"`
+}
+
+// FrontendStatus defines the observed state of Frontend
+type FrontendStatus struct {
+	// ObservedGeneration is the last Frontend generation processed by the controller.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Replicas is the number of pods of the owned Deployment.
+	Replicas int32 `json:"replicas,omitempty"`
+	// ReadyReplicas is the number of ready pods of the owned Deployment.
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+	// Selector is the label selector of the Frontend pods, used by the scale subresource.
+	Selector string `json:"selector,omitempty"`
+	// ContentRevision identifies the content currently rendered into the ConfigMap.
+	ContentRevision string `json:"contentRevision,omitempty"`
+	// Rollout is the state of the content rollout. Only set for the BlueGreen and Canary strategies.
+	// +optional
+	Rollout *RolloutStatus `json:"rollout,omitempty"`
+
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.selector
+// +kubebuilder:printcolumn:name="Image",type=string,JSONPath=`.spec.image`
+// +kubebuilder:printcolumn:name="Desired",type=integer,JSONPath=`.spec.replicas`
+// +kubebuilder:printcolumn:name="Ready",type=integer,JSONPath=`.status.readyReplicas`
+// +kubebuilder:printcolumn:name="Revision",type=string,JSONPath=`.status.contentRevision`
+// +kubebuilder:printcolumn:name="Rollout",type=string,JSONPath=`.status.rollout.phase`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+// +kubebuilder:validation:XValidation:rule="self.metadata.name.matches('^[a-z]([-a-z0-9]*[a-z0-9])?$') && size(self.metadata.name) <= 63",message="name must be a DNS-1035 label, as it is used for the Service"
+type Frontend struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   FrontendSpec   `json:"spec"`
+	Status FrontendStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// FrontendList contains a list of Frontend
+type FrontendList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []Frontend `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Frontend{}, &FrontendList{})
+}
@@ -0,0 +1,99 @@
+package ctrl
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// Controller is a controller the server can run, selected by name with the --controllers flag.
+type Controller struct {
+	// Name selects the controller and names its controllers.<name> config section.
+	Name string
+	// EnabledByDefault controllers are selected by "*".
+	EnabledByDefault bool
+	// Setup adds the controller to the manager, reading its settings from config, the
+	// controllers.<name> section of the server config.
+	Setup func(mgr manager.Manager, config *viper.Viper) error
+}
+
+// controllers are the registered controllers by name.
+var controllers = map[string]Controller{}
+
+// RegisterController makes a controller available to the server. It panics if the name is
+// taken, as registrations happen in init functions.
+func RegisterController(c Controller) {
+	if c.Name == "" || strings.ContainsAny(c.Name, "*,-.") {
+		panic(fmt.Sprintf("invalid controller name %q", c.Name))
+	}
+	if _, ok := controllers[c.Name]; ok {
+		panic(fmt.Sprintf("controller %q registered twice", c.Name))
+	}
+	controllers[c.Name] = c
+}
+
+// ControllerNames returns the names of the registered controllers.
+func ControllerNames() []string {
+	return slices.Sorted(maps.Keys(controllers))
+}
+
+// EnabledControllers returns the names of the controllers selected like with the
+// --controllers flag of kube-controller-manager: "*" selects the controllers enabled by
+// default, "<name>" selects a controller and "-<name>" deselects it, whatever the order.
+func EnabledControllers(selectors []string) ([]string, error) {
+	enabled, disabled := sets.New[string](), sets.New[string]()
+	for _, selector := range selectors {
+		selector = strings.TrimSpace(selector)
+		if selector == "*" {
+			for name, c := range controllers {
+				if c.EnabledByDefault {
+					enabled.Insert(name)
+				}
+			}
+			continue
+		}
+		name, disable := strings.CutPrefix(selector, "-")
+		if _, ok := controllers[name]; !ok {
+			return nil, fmt.Errorf("unknown controller %q, must be one of %s", name, strings.Join(ControllerNames(), ", "))
+		}
+		if disable {
+			disabled.Insert(name)
+		} else {
+			enabled.Insert(name)
+		}
+	}
+	return sets.List(enabled.Difference(disabled)), nil
+}
+
+// SetupControllers adds the named controllers to the manager, passing each the
+// controllers.<name> section of config.
+func SetupControllers(mgr manager.Manager, names []string, config *viper.Viper) error {
+	for _, name := range names {
+		c, ok := controllers[name]
+		if !ok {
+			return fmt.Errorf("unknown controller %q", name)
+		}
+		if err := c.Setup(mgr, controllerConfig(config, name)); err != nil {
+			return fmt.Errorf("failed to set up %s controller: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// controllerConfig returns the controllers.<name> section of config. Unlike viper.Sub, it
+// keeps the values of bound flags and environment variables.
+func controllerConfig(config *viper.Viper, name string) *viper.Viper {
+	section := viper.New()
+	prefix := "controllers." + name + "."
+	for _, key := range config.AllKeys() {
+		if rest, ok := strings.CutPrefix(key, prefix); ok {
+			section.Set(rest, config.Get(key))
+		}
+	}
+	return section
+}
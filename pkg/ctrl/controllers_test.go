@@ -0,0 +1,60 @@
+package ctrl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestControllerNames(t *testing.T) {
+	require.Equal(t, []string{"deployment", "frontend", "imageupdate", "reloader"}, ControllerNames())
+}
+
+func TestEnabledControllers(t *testing.T) {
+	for _, tc := range []struct {
+		selectors []string
+		want      []string
+		err       string
+	}{
+		{selectors: nil, want: []string{}},
+		{selectors: []string{"*"}, want: []string{"frontend"}},
+		{selectors: []string{"*", "reloader", "deployment"}, want: []string{"deployment", "frontend", "reloader"}},
+		{selectors: []string{"-frontend", "*"}, want: []string{}},
+		{selectors: []string{"*", "imageupdate", "-imageupdate"}, want: []string{"frontend"}},
+		{selectors: []string{"*", "-nginx"}, err: `unknown controller "nginx"`},
+	} {
+		got, err := EnabledControllers(tc.selectors)
+		if tc.err != "" {
+			require.ErrorContains(t, err, tc.err)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, tc.want, got, "selectors %v", tc.selectors)
+	}
+}
+
+func TestControllerConfig(t *testing.T) {
+	config := viper.New()
+	f := pflag.NewFlagSet("server", pflag.ContinueOnError)
+	f.Duration("frontend-max-delay", time.Minute, "")
+	require.NoError(t, config.BindPFlag("controllers.frontend.max-delay", f.Lookup("frontend-max-delay")))
+	require.NoError(t, f.Parse([]string{"--frontend-max-delay=2m"}))
+	config.Set("controllers.frontend.burst", 7)
+	config.Set("controllers.reloader.burst", 9)
+
+	section := controllerConfig(config, "frontend")
+	require.ElementsMatch(t, []string{"max-delay", "burst"}, section.AllKeys())
+
+	opts := frontendOptionsFromConfig(section)
+	require.Equal(t, 2*time.Minute, opts.MaxDelay, "bound flags should be read")
+	require.Equal(t, 7, opts.Burst)
+	require.Equal(t, float64(defaultQPS), opts.QPS, "unset options should keep their defaults")
+	require.Equal(t, defaultReconcileTimeout, opts.ReconcileTimeout)
+	require.False(t, opts.Paused)
+
+	config.Set("controllers.frontend.paused", true)
+	require.True(t, frontendOptionsFromConfig(controllerConfig(config, "frontend")).Paused)
+}
@@ -2,41 +2,337 @@ package ctrl
 
 import (
 	context "context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/yaml"
 )
 
+const (
+	// policyKey is the key of the ConfigMap data holding the Deployment policy.
+	policyKey = "policy.yaml"
+	// violationsAnnotation lists the policy violations of a Deployment, one per line.
+	violationsAnnotation = "policy.oleksandr-san.io/violations"
+	// policyFieldManager is the field manager of the defaults patched into Deployments.
+	policyFieldManager = "deployment-policy-controller"
+)
+
+// Reasons of the events recorded on Deployments and the policy ConfigMap.
+const (
+	eventReasonPolicyViolation = "PolicyViolation"
+	eventReasonPolicyDefaulted = "PolicyDefaulted"
+	eventReasonInvalidPolicy   = "InvalidPolicy"
+)
+
+// PolicyMode selects what the policy controller does about non-compliant Deployments.
+type PolicyMode string
+
+const (
+	// PolicyModeReport only reports violations as events and annotations.
+	PolicyModeReport PolicyMode = "Report"
+	// PolicyModeEnforce patches missing defaults into Deployments and reports the violations
+	// that remain.
+	PolicyModeEnforce PolicyMode = "Enforce"
+)
+
+// DeploymentPolicy is the rule set enforced on Deployments, read from the policy.yaml key of
+// the policy ConfigMap.
+type DeploymentPolicy struct {
+	// Mode is Report (default) or Enforce.
+	Mode PolicyMode `json:"mode,omitempty"`
+	// ExcludedNamespaces are not checked.
+	ExcludedNamespaces []string `json:"excludedNamespaces,omitempty"`
+	// RequiredLabels must be set on every Deployment.
+	RequiredLabels []string `json:"requiredLabels,omitempty"`
+	// DefaultLabels are set on Deployments missing them in Enforce mode.
+	DefaultLabels map[string]string `json:"defaultLabels,omitempty"`
+	// AllowedRegistries are the registries, or registry path prefixes like ghcr.io/org, that
+	// container images may be pulled from. Any registry is allowed if empty.
+	AllowedRegistries []string `json:"allowedRegistries,omitempty"`
+	// RequiredRequests are the resources every container must request, like cpu and memory.
+	RequiredRequests []corev1.ResourceName `json:"requiredRequests,omitempty"`
+	// DefaultRequests are set on containers missing them in Enforce mode.
+	DefaultRequests corev1.ResourceList `json:"defaultRequests,omitempty"`
+}
+
+// parseDeploymentPolicy reads the policy from the data of the policy ConfigMap.
+func parseDeploymentPolicy(data string) (*DeploymentPolicy, error) {
+	policy := &DeploymentPolicy{}
+	if err := yaml.UnmarshalStrict([]byte(data), policy); err != nil {
+		return nil, err
+	}
+	switch policy.Mode {
+	case "":
+		policy.Mode = PolicyModeReport
+	case PolicyModeReport, PolicyModeEnforce:
+	default:
+		return nil, fmt.Errorf("unsupported mode %q, must be %s or %s", policy.Mode, PolicyModeReport, PolicyModeEnforce)
+	}
+	return policy, nil
+}
+
+// applyDefaults sets the default labels and resource requests missing from dep and returns
+// what it set.
+func (p *DeploymentPolicy) applyDefaults(dep *appsv1.Deployment) []string {
+	var defaulted []string
+	for _, key := range slices.Sorted(maps.Keys(p.DefaultLabels)) {
+		if _, ok := dep.Labels[key]; ok {
+			continue
+		}
+		if dep.Labels == nil {
+			dep.Labels = map[string]string{}
+		}
+		dep.Labels[key] = p.DefaultLabels[key]
+		defaulted = append(defaulted, fmt.Sprintf("label %s=%s", key, p.DefaultLabels[key]))
+	}
+	for i := range dep.Spec.Template.Spec.Containers {
+		container := &dep.Spec.Template.Spec.Containers[i]
+		for _, name := range slices.Sorted(maps.Keys(p.DefaultRequests)) {
+			if _, ok := container.Resources.Requests[name]; ok {
+				continue
+			}
+			if container.Resources.Requests == nil {
+				container.Resources.Requests = corev1.ResourceList{}
+			}
+			quantity := p.DefaultRequests[name]
+			container.Resources.Requests[name] = quantity
+			defaulted = append(defaulted, fmt.Sprintf("%s request %s of container %s", name, quantity.String(), container.Name))
+		}
+	}
+	return defaulted
+}
+
+// check returns the violations of the policy by dep.
+func (p *DeploymentPolicy) check(dep *appsv1.Deployment) []string {
+	var violations []string
+	for _, key := range p.RequiredLabels {
+		if _, ok := dep.Labels[key]; !ok {
+			violations = append(violations, fmt.Sprintf("missing required label %s", key))
+		}
+	}
+	podSpec := dep.Spec.Template.Spec
+	for _, container := range slices.Concat(podSpec.InitContainers, podSpec.Containers) {
+		if !p.registryAllowed(container.Image) {
+			violations = append(violations, fmt.Sprintf("image %s of container %s is not from an allowed registry", container.Image, container.Name))
+		}
+		for _, name := range p.RequiredRequests {
+			if _, ok := container.Resources.Requests[name]; !ok {
+				violations = append(violations, fmt.Sprintf("container %s has no %s request", container.Name, name))
+			}
+		}
+	}
+	return violations
+}
+
+func (p *DeploymentPolicy) registryAllowed(image string) bool {
+	if len(p.AllowedRegistries) == 0 {
+		return true
+	}
+	ref := normalizeImage(image)
+	for _, allowed := range p.AllowedRegistries {
+		allowed = strings.TrimSuffix(allowed, "/")
+		if ref == allowed || strings.HasPrefix(ref, allowed+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeImage returns image with its registry, like the container runtime resolves it:
+// nginx becomes docker.io/library/nginx.
+func normalizeImage(image string) string {
+	first, rest, found := strings.Cut(image, "/")
+	if !found {
+		return "docker.io/library/" + image
+	}
+	if !strings.ContainsAny(first, ".:") && first != "localhost" {
+		return "docker.io/" + image
+	}
+	return first + "/" + rest
+}
+
+// DeploymentOptions configures the Deployment policy controller.
+type DeploymentOptions struct {
+	// PolicyConfigMap is the ConfigMap holding the policy. Without it no rules are enforced.
+	PolicyConfigMap types.NamespacedName
+}
+
+// DeploymentReconciler checks Deployments against the policy read from a ConfigMap. It
+// reports violations as Warning events and in the violations annotation, and patches missing
+// defaults in Enforce mode. It also restarts Deployments as requested by their restart
+// annotations.
 type DeploymentReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	Options  DeploymentOptions
 }
 
 func (r *DeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	log.Info().Msgf("Reconciling Deployment: %s/%s", req.Namespace, req.Name)
+	policy, err := r.loadPolicy(ctx)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	dep := &appsv1.Deployment{}
+	if err := r.Get(ctx, req.NamespacedName, dep); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !dep.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	patch := client.StrategicMergeFrom(dep.DeepCopy())
+	var defaulted, violations []string
+	if !slices.Contains(policy.ExcludedNamespaces, dep.Namespace) {
+		if policy.Mode == PolicyModeEnforce {
+			defaulted = policy.applyDefaults(dep)
+		}
+		violations = policy.check(dep)
+	}
+	// Clears the violations reported before the namespace was excluded
+	changed := setViolations(dep, violations) || len(defaulted) > 0
+
+	now := time.Now()
+	plan, err := planRestart(dep, now)
+	if err != nil {
+		// Retrying will not help, wait for the annotations to change
+		r.Recorder.Event(dep, corev1.EventTypeWarning, eventReasonInvalidRestart, err.Error())
+	}
+	if plan.due {
+		restart(dep, now)
+		changed = true
+	}
+	result := ctrl.Result{RequeueAfter: plan.requeueAfter(now)}
+	if !changed {
+		return result, nil
+	}
+
+	if err := r.Patch(ctx, dep, patch, client.FieldOwner(policyFieldManager)); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if plan.due {
+		log.Info().Msgf("Restarted Deployment: %s/%s (%s)", dep.Namespace, dep.Name, plan.reason)
+		r.Recorder.Eventf(dep, corev1.EventTypeNormal, eventReasonRestarted, "Restarted for %s", plan.reason)
+	}
+	if len(defaulted) > 0 || len(violations) > 0 {
+		log.Info().Strs("defaulted", defaulted).Strs("violations", violations).
+			Msgf("Applied Deployment policy: %s/%s", dep.Namespace, dep.Name)
+	}
+	if len(defaulted) > 0 {
+		r.Recorder.Eventf(dep, corev1.EventTypeNormal, eventReasonPolicyDefaulted, "Set %s", strings.Join(defaulted, ", "))
+	}
+	for _, violation := range violations {
+		r.Recorder.Event(dep, corev1.EventTypeWarning, eventReasonPolicyViolation, violation)
+	}
+	return result, nil
+}
 
-	deploy := &appsv1.Deployment{}
-	err := r.Client.Get(ctx, req.NamespacedName, deploy)
+// loadPolicy returns the policy of the ConfigMap. Missing or invalid policies enforce no rules.
+func (r *DeploymentReconciler) loadPolicy(ctx context.Context) (*DeploymentPolicy, error) {
+	cm := &corev1.ConfigMap{}
+	if err := r.Get(ctx, r.Options.PolicyConfigMap, cm); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return nil, err
+		}
+		return &DeploymentPolicy{Mode: PolicyModeReport}, nil
+	}
+	policy, err := parseDeploymentPolicy(cm.Data[policyKey])
 	if err != nil {
-		log.Error().Err(err).Msgf("Failed to get Deployment: %s/%s", req.Namespace, req.Name)
-	} else {
-		log.Info().Msgf("Got Deployment: %s/%s", req.Namespace, req.Name)
+		// Retrying will not help, wait for the ConfigMap to change
+		r.Recorder.Eventf(cm, corev1.EventTypeWarning, eventReasonInvalidPolicy, "Invalid %s: %v", policyKey, err)
+		return &DeploymentPolicy{Mode: PolicyModeReport}, nil
 	}
-	return ctrl.Result{}, nil
+	return policy, nil
+}
+
+// setViolations records the violations in the annotation of dep and reports whether it changed.
+func setViolations(dep *appsv1.Deployment, violations []string) bool {
+	value := strings.Join(violations, "\n")
+	current, ok := dep.Annotations[violationsAnnotation]
+	switch {
+	case value == "" && !ok:
+		return false
+	case value == "":
+		delete(dep.Annotations, violationsAnnotation)
+	case value == current:
+		return false
+	default:
+		if dep.Annotations == nil {
+			dep.Annotations = map[string]string{}
+		}
+		dep.Annotations[violationsAnnotation] = value
+	}
+	return true
+}
+
+// allDeployments enqueues every Deployment when the policy changes.
+func (r *DeploymentReconciler) allDeployments(ctx context.Context, _ client.Object) []reconcile.Request {
+	var deps appsv1.DeploymentList
+	if err := r.List(ctx, &deps); err != nil {
+		log.Error().Err(err).Msg("Failed to list Deployments for the changed policy")
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(deps.Items))
+	for _, dep := range deps.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&dep)})
+	}
+	return requests
+}
+
+func init() {
+	RegisterController(Controller{
+		Name: "deployment",
+		Setup: func(mgr manager.Manager, config *viper.Viper) error {
+			var opts DeploymentOptions
+			if key := config.GetString("policy-configmap"); key != "" {
+				namespace, name, err := cache.SplitMetaNamespaceKey(key)
+				if err != nil || namespace == "" {
+					return fmt.Errorf("policy-configmap %q must be set as <namespace>/<name>", key)
+				}
+				opts.PolicyConfigMap = types.NamespacedName{Namespace: namespace, Name: name}
+			}
+			return AddDeploymentController(mgr, opts)
+		},
+	})
 }
 
-func AddDeploymentController(mgr manager.Manager) error {
+func AddDeploymentController(mgr manager.Manager, opts DeploymentOptions) error {
 	r := &DeploymentReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: newDedupRecorder(mgr.GetEventRecorderFor(policyFieldManager)),
+		Options:  opts,
 	}
+	isPolicy := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return client.ObjectKeyFromObject(obj) == opts.PolicyConfigMap
+	})
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&appsv1.Deployment{}).
+		For(&appsv1.Deployment{}, builder.WithPredicates(predicate.Or(
+			predicate.GenerationChangedPredicate{},
+			predicate.LabelChangedPredicate{},
+			predicate.AnnotationChangedPredicate{},
+		))).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.allDeployments), builder.WithPredicates(isPolicy)).
 		WithOptions(controller.Options{MaxConcurrentReconciles: 1}).
 		Complete(r)
 }
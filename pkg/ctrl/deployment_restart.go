@@ -0,0 +1,105 @@
+package ctrl
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+const (
+	// restartScheduleAnnotation restarts a Deployment on a cron schedule, like "0 3 * * *".
+	// A CRON_TZ=<zone> prefix selects the time zone, which defaults to UTC.
+	restartScheduleAnnotation = "restart.oleksandr-san.io/schedule"
+	// restartAfterAnnotation restarts a Deployment once at an RFC 3339 timestamp.
+	restartAfterAnnotation = "restart.oleksandr-san.io/restart-after"
+	// lastRestartAnnotation records when the controller last restarted a Deployment.
+	lastRestartAnnotation = "restart.oleksandr-san.io/last-restart"
+	// restartedAtAnnotation is stamped onto the pod template to roll the pods, like
+	// kubectl rollout restart does.
+	restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+)
+
+// Reasons of the events recorded for restarts of Deployments.
+const (
+	eventReasonRestarted      = "Restarted"
+	eventReasonInvalidRestart = "InvalidRestart"
+)
+
+// missedRestartWindow is how late a scheduled restart may still run, e.g. after the controller
+// was down. Older missed restarts are skipped.
+const missedRestartWindow = 5 * time.Minute
+
+// restartPlan is when a Deployment is restarted according to its restart annotations.
+type restartPlan struct {
+	// due reports whether the Deployment is restarted now, and reason why.
+	due    bool
+	reason string
+	// next is the time of the next restart, zero if none is planned.
+	next time.Time
+}
+
+// requeueAfter returns when to reconcile the Deployment again for its next restart, 0 if none is planned.
+func (p restartPlan) requeueAfter(now time.Time) time.Duration {
+	if p.next.IsZero() {
+		return 0
+	}
+	return max(p.next.Sub(now), time.Second)
+}
+
+// planRestart derives the restart plan of dep from its annotations. An invalid annotation is
+// reported in the error, and the plan is derived from the valid one.
+func planRestart(dep *appsv1.Deployment, now time.Time) (restartPlan, error) {
+	// Schedules without CRON_TZ run in the location of the time they are computed from
+	now = now.UTC()
+	var plan restartPlan
+	var lastRestart time.Time
+	if value, ok := dep.Annotations[lastRestartAnnotation]; ok {
+		// An unreadable last restart is treated as none, so that the schedule goes on
+		lastRestart, _ = time.Parse(time.RFC3339, value)
+	}
+	planNext := func(next time.Time) {
+		if plan.next.IsZero() || next.Before(plan.next) {
+			plan.next = next
+		}
+	}
+
+	var errs []error
+	if value, ok := dep.Annotations[restartAfterAnnotation]; ok {
+		if restartAfter, err := time.Parse(time.RFC3339, value); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s annotation %q: %w", restartAfterAnnotation, value, err))
+		} else if restartAfter.After(now) {
+			planNext(restartAfter)
+		} else if lastRestart.Before(restartAfter.Truncate(time.Second)) {
+			plan.due = true
+			plan.reason = fmt.Sprintf("restart after %s", value)
+		}
+	}
+
+	if value, ok := dep.Annotations[restartScheduleAnnotation]; ok {
+		if schedule, err := cron.ParseStandard(value); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s annotation %q: %w", restartScheduleAnnotation, value, err))
+		} else {
+			// The latest scheduled time within the window, if any
+			var scheduled time.Time
+			for next := schedule.Next(now.Add(-missedRestartWindow)); !next.After(now); next = schedule.Next(next) {
+				scheduled = next
+			}
+			if !scheduled.IsZero() && lastRestart.Before(scheduled) && !plan.due {
+				plan.due = true
+				plan.reason = fmt.Sprintf("schedule %s", value)
+			}
+			planNext(schedule.Next(now))
+		}
+	}
+	return plan, errors.Join(errs...)
+}
+
+// restart rolls the pods of dep and records the restart.
+func restart(dep *appsv1.Deployment, now time.Time) {
+	timestamp := now.UTC().Format(time.RFC3339)
+	dep.Spec.Template.Annotations = mergeMaps(dep.Spec.Template.Annotations, map[string]string{restartedAtAnnotation: timestamp})
+	dep.Annotations = mergeMaps(dep.Annotations, map[string]string{lastRestartAnnotation: timestamp})
+}
@@ -0,0 +1,218 @@
+package ctrl
+
+import (
+	context "context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestPlanRestart(t *testing.T) {
+	now := time.Date(2025, 7, 1, 3, 2, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name        string
+		annotations map[string]string
+		due         bool
+		next        time.Time
+		err         string
+	}{
+		{name: "no annotations"},
+		{
+			name:        "scheduled restart",
+			annotations: map[string]string{restartScheduleAnnotation: "0 3 * * *"},
+			due:         true,
+			next:        time.Date(2025, 7, 2, 3, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "scheduled restart done",
+			annotations: map[string]string{
+				restartScheduleAnnotation: "0 3 * * *",
+				lastRestartAnnotation:     "2025-07-01T03:00:00Z",
+			},
+			next: time.Date(2025, 7, 2, 3, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "missed scheduled restart",
+			annotations: map[string]string{restartScheduleAnnotation: "50 2 * * *"},
+			next:        time.Date(2025, 7, 2, 2, 50, 0, 0, time.UTC),
+		},
+		{
+			name:        "scheduled restart in time zone",
+			annotations: map[string]string{restartScheduleAnnotation: "CRON_TZ=Europe/Kyiv 0 6 * * *"},
+			due:         true,
+			next:        time.Date(2025, 7, 2, 3, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "restart after",
+			annotations: map[string]string{restartAfterAnnotation: "2025-07-01T01:00:00Z"},
+			due:         true,
+		},
+		{
+			name: "restart after done",
+			annotations: map[string]string{
+				restartAfterAnnotation: "2025-07-01T01:00:00Z",
+				lastRestartAnnotation:  "2025-07-01T01:00:04Z",
+			},
+		},
+		{
+			name:        "restart after in the future",
+			annotations: map[string]string{restartAfterAnnotation: "2025-07-01T12:00:00Z"},
+			next:        time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "earliest next restart",
+			annotations: map[string]string{
+				restartScheduleAnnotation: "0 3 * * *",
+				restartAfterAnnotation:    "2025-07-01T12:00:00Z",
+				lastRestartAnnotation:     "2025-07-01T03:00:00Z",
+			},
+			next: time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "latest scheduled restart",
+			annotations: map[string]string{
+				restartScheduleAnnotation: "*/1 * * * *",
+				lastRestartAnnotation:     "2025-07-01T03:00:00Z",
+			},
+			due:  true,
+			next: time.Date(2025, 7, 1, 3, 3, 0, 0, time.UTC),
+		},
+		{
+			name:        "invalid schedule",
+			annotations: map[string]string{restartScheduleAnnotation: "nightly"},
+			err:         "invalid restart.oleksandr-san.io/schedule annotation",
+		},
+		{
+			name:        "invalid restart after",
+			annotations: map[string]string{restartAfterAnnotation: "tomorrow"},
+			err:         "invalid restart.oleksandr-san.io/restart-after annotation",
+		},
+		{
+			name: "invalid schedule with restart after",
+			annotations: map[string]string{
+				restartScheduleAnnotation: "nightly",
+				restartAfterAnnotation:    "2025-07-01T01:00:00Z",
+			},
+			due: true,
+			err: "invalid restart.oleksandr-san.io/schedule annotation",
+		},
+		{
+			name: "invalid restart after with schedule",
+			annotations: map[string]string{
+				restartScheduleAnnotation: "0 3 * * *",
+				restartAfterAnnotation:    "tomorrow",
+			},
+			due:  true,
+			next: time.Date(2025, 7, 2, 3, 0, 0, 0, time.UTC),
+			err:  "invalid restart.oleksandr-san.io/restart-after annotation",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dep := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations}}
+			plan, err := planRestart(dep, now)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.due, plan.due)
+			require.True(t, tc.next.Equal(plan.next), "next restart %s, want %s", plan.next, tc.next)
+		})
+	}
+}
+
+func TestPlanRestart_LocalTimeZone(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	now := time.Date(2025, 7, 1, 3, 2, 0, 0, time.UTC).In(newYork)
+
+	dep := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{restartScheduleAnnotation: "0 3 * * *"}}}
+	plan, err := planRestart(dep, now)
+	require.NoError(t, err)
+	require.True(t, plan.due, "schedules without CRON_TZ should run in UTC")
+	require.True(t, time.Date(2025, 7, 2, 3, 0, 0, 0, time.UTC).Equal(plan.next), "next restart %s", plan.next)
+}
+
+func TestDeploymentReconciler_Restart(t *testing.T) {
+	dep := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web",
+			Namespace: "default",
+			Annotations: map[string]string{
+				restartAfterAnnotation:    time.Now().Add(-time.Minute).UTC().Format(time.RFC3339),
+				restartScheduleAnnotation: "0 3 * * *",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "nginx", Image: "nginx"}},
+		}}},
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := &DeploymentReconciler{
+		Client:   fake.NewClientBuilder().WithObjects(dep).Build(),
+		Recorder: recorder,
+		Options:  DeploymentOptions{PolicyConfigMap: client.ObjectKey{Name: "deployment-policy", Namespace: "default"}},
+	}
+
+	ctx := context.Background()
+	key := client.ObjectKeyFromObject(dep)
+	result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+	require.Positive(t, result.RequeueAfter, "the next scheduled restart should be requeued")
+	require.LessOrEqual(t, result.RequeueAfter, 24*time.Hour)
+
+	var got appsv1.Deployment
+	require.NoError(t, r.Get(ctx, key, &got))
+	restartedAt := got.Spec.Template.Annotations[restartedAtAnnotation]
+	require.NotEmpty(t, restartedAt, "the pods should be restarted")
+	require.Equal(t, restartedAt, got.Annotations[lastRestartAnnotation])
+	require.Contains(t, <-recorder.Events, "Normal Restarted Restarted for restart after")
+
+	// Restarts are not repeated
+	_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+	require.NoError(t, r.Get(ctx, key, &got))
+	require.Equal(t, restartedAt, got.Spec.Template.Annotations[restartedAtAnnotation])
+}
+
+func TestDeploymentReconciler_InvalidRestart(t *testing.T) {
+	dep := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web",
+			Namespace: "default",
+			Annotations: map[string]string{
+				restartAfterAnnotation:    time.Now().Add(-time.Minute).UTC().Format(time.RFC3339),
+				restartScheduleAnnotation: "nightly",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "nginx", Image: "nginx"}},
+		}}},
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := &DeploymentReconciler{
+		Client:   fake.NewClientBuilder().WithObjects(dep).Build(),
+		Recorder: recorder,
+		Options:  DeploymentOptions{PolicyConfigMap: client.ObjectKey{Name: "deployment-policy", Namespace: "default"}},
+	}
+
+	ctx := context.Background()
+	key := client.ObjectKeyFromObject(dep)
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+	require.Contains(t, <-recorder.Events, "Warning InvalidRestart invalid restart.oleksandr-san.io/schedule annotation")
+
+	// The invalid schedule does not disable the valid restart-after annotation
+	var got appsv1.Deployment
+	require.NoError(t, r.Get(ctx, key, &got))
+	require.NotEmpty(t, got.Spec.Template.Annotations[restartedAtAnnotation], "the pods should be restarted")
+}
@@ -9,8 +9,12 @@ import (
 	"github.com/stretchr/testify/require"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func TestDeploymentReconciler_BasicFlow(t *testing.T) {
@@ -18,7 +22,7 @@ func TestDeploymentReconciler_BasicFlow(t *testing.T) {
 	defer cleanup()
 
 	// Register the controller before starting the manager
-	err := AddDeploymentController(mgr)
+	err := AddDeploymentController(mgr, DeploymentOptions{})
 	require.NoError(t, err)
 
 	go func() {
@@ -59,3 +63,194 @@ func TestDeploymentReconciler_BasicFlow(t *testing.T) {
 }
 
 func int32Ptr(i int32) *int32 { return &i }
+
+func TestParseDeploymentPolicy(t *testing.T) {
+	policy, err := parseDeploymentPolicy(`
+requiredLabels: [team]
+allowedRegistries: [ghcr.io/oleksandr-san]
+requiredRequests: [cpu, memory]
+defaultRequests:
+  cpu: 100m
+`)
+	require.NoError(t, err)
+	require.Equal(t, PolicyModeReport, policy.Mode, "policies only report violations by default")
+	require.Equal(t, []string{"team"}, policy.RequiredLabels)
+	require.Equal(t, resource.MustParse("100m"), policy.DefaultRequests[corev1.ResourceCPU])
+
+	_, err = parseDeploymentPolicy("mode: Fix")
+	require.ErrorContains(t, err, "unsupported mode")
+	_, err = parseDeploymentPolicy("requiredLabel: [team]")
+	require.Error(t, err, "unknown fields should be rejected")
+}
+
+func TestDeploymentPolicy_Check(t *testing.T) {
+	policy := &DeploymentPolicy{
+		RequiredLabels:    []string{"team"},
+		AllowedRegistries: []string{"docker.io/library", "ghcr.io/oleksandr-san/"},
+		RequiredRequests:  []corev1.ResourceName{corev1.ResourceCPU},
+	}
+	dep := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"team": "web"}},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "init", Image: "ghcr.io/oleksandr-san/init:v1", Resources: cpuRequest("10m")}},
+			Containers:     []corev1.Container{{Name: "nginx", Image: "nginx:alpine", Resources: cpuRequest("100m")}},
+		}}},
+	}
+	require.Empty(t, policy.check(dep))
+
+	delete(dep.Labels, "team")
+	dep.Spec.Template.Spec.Containers = append(dep.Spec.Template.Spec.Containers,
+		corev1.Container{Name: "sidecar", Image: "ghcr.io/other/sidecar:v1"})
+	require.Equal(t, []string{
+		"missing required label team",
+		"image ghcr.io/other/sidecar:v1 of container sidecar is not from an allowed registry",
+		"container sidecar has no cpu request",
+	}, policy.check(dep))
+}
+
+func TestDeploymentPolicy_ApplyDefaults(t *testing.T) {
+	policy := &DeploymentPolicy{
+		DefaultLabels:   map[string]string{"team": "unknown"},
+		DefaultRequests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+	}
+	dep := &appsv1.Deployment{Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+		Containers: []corev1.Container{{Name: "a"}, {Name: "b", Resources: cpuRequest("1")}},
+	}}}}
+	require.Equal(t, []string{"label team=unknown", "cpu request 100m of container a"}, policy.applyDefaults(dep))
+	require.Equal(t, "unknown", dep.Labels["team"])
+	require.Equal(t, resource.MustParse("100m"), dep.Spec.Template.Spec.Containers[0].Resources.Requests[corev1.ResourceCPU])
+	require.Equal(t, resource.MustParse("1"), dep.Spec.Template.Spec.Containers[1].Resources.Requests[corev1.ResourceCPU])
+	require.Empty(t, policy.applyDefaults(dep), "defaults should only be set once")
+}
+
+func TestNormalizeImage(t *testing.T) {
+	require.Equal(t, "docker.io/library/nginx:alpine", normalizeImage("nginx:alpine"))
+	require.Equal(t, "docker.io/bitnami/nginx", normalizeImage("bitnami/nginx"))
+	require.Equal(t, "ghcr.io/oleksandr-san/app:v1", normalizeImage("ghcr.io/oleksandr-san/app:v1"))
+	require.Equal(t, "localhost:5000/app", normalizeImage("localhost:5000/app"))
+	require.Equal(t, "localhost/app", normalizeImage("localhost/app"))
+}
+
+func TestDeploymentReconciler_Report(t *testing.T) {
+	policyName := client.ObjectKey{Name: "deployment-policy", Namespace: "default"}
+	dep := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "nginx", Image: "nginx"}},
+		}}},
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := &DeploymentReconciler{
+		Client: fake.NewClientBuilder().WithObjects(dep, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: policyName.Name, Namespace: policyName.Namespace},
+			Data:       map[string]string{"policy.yaml": "requiredRequests: [memory]\ndefaultRequests: {memory: 64Mi}"},
+		}).Build(),
+		Recorder: recorder,
+		Options:  DeploymentOptions{PolicyConfigMap: policyName},
+	}
+
+	ctx := context.Background()
+	key := client.ObjectKeyFromObject(dep)
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+
+	var got appsv1.Deployment
+	require.NoError(t, r.Get(ctx, key, &got))
+	require.Equal(t, "container nginx has no memory request", got.Annotations[violationsAnnotation])
+	require.Empty(t, got.Spec.Template.Spec.Containers[0].Resources.Requests, "defaults are only set in Enforce mode")
+	require.Equal(t, "Warning PolicyViolation container nginx has no memory request", <-recorder.Events)
+}
+
+func TestDeploymentReconciler_ExcludedNamespace(t *testing.T) {
+	policyName := client.ObjectKey{Name: "deployment-policy", Namespace: "default"}
+	dep := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "web",
+			Namespace:   "kube-system",
+			Annotations: map[string]string{violationsAnnotation: "container nginx has no memory request"},
+		},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "nginx", Image: "nginx"}},
+		}}},
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := &DeploymentReconciler{
+		Client: fake.NewClientBuilder().WithObjects(dep, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: policyName.Name, Namespace: policyName.Namespace},
+			Data:       map[string]string{"policy.yaml": "requiredRequests: [memory]\nexcludedNamespaces: [kube-system]"},
+		}).Build(),
+		Recorder: recorder,
+		Options:  DeploymentOptions{PolicyConfigMap: policyName},
+	}
+
+	ctx := context.Background()
+	key := client.ObjectKeyFromObject(dep)
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+
+	var got appsv1.Deployment
+	require.NoError(t, r.Get(ctx, key, &got))
+	require.NotContains(t, got.Annotations, violationsAnnotation, "violations reported before the exclusion should be cleared")
+	require.Empty(t, recorder.Events)
+}
+
+func TestDeploymentReconciler_Policy(t *testing.T) {
+	mgr, k8sClient, _, cleanup := testutil.StartTestManager(t)
+	defer cleanup()
+
+	policyName := client.ObjectKey{Name: "deployment-policy", Namespace: "default"}
+	require.NoError(t, AddDeploymentController(mgr, DeploymentOptions{PolicyConfigMap: policyName}))
+
+	ctx := context.Background()
+	dep := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy-deployment", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "policy"}},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "policy"}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "nginx", Image: "quay.io/nginx/nginx"}}},
+			},
+		},
+	}
+	require.NoError(t, k8sClient.Create(ctx, dep))
+
+	// Creating the policy checks the existing Deployments
+	require.NoError(t, k8sClient.Create(ctx, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: policyName.Name, Namespace: policyName.Namespace},
+		Data: map[string]string{"policy.yaml": `
+mode: Enforce
+requiredLabels: [team]
+allowedRegistries: [docker.io]
+requiredRequests: [cpu]
+defaultRequests:
+  cpu: 50m
+`},
+	}))
+
+	key := client.ObjectKeyFromObject(dep)
+	require.Eventually(t, func() bool {
+		if err := k8sClient.Get(ctx, key, dep); err != nil {
+			return false
+		}
+		_, ok := dep.Spec.Template.Spec.Containers[0].Resources.Requests[corev1.ResourceCPU]
+		return ok && dep.Annotations[violationsAnnotation] != ""
+	}, 10*time.Second, 100*time.Millisecond, "defaults should be set and the remaining violations reported")
+	require.Equal(t, "missing required label team\nimage quay.io/nginx/nginx of container nginx is not from an allowed registry",
+		dep.Annotations[violationsAnnotation])
+
+	// Fixed violations are no longer reported
+	dep.Labels = map[string]string{"team": "web"}
+	dep.Spec.Template.Spec.Containers[0].Image = "nginx:alpine"
+	require.NoError(t, k8sClient.Update(ctx, dep))
+	require.Eventually(t, func() bool {
+		if err := k8sClient.Get(ctx, key, dep); err != nil {
+			return false
+		}
+		_, ok := dep.Annotations[violationsAnnotation]
+		return !ok
+	}, 10*time.Second, 100*time.Millisecond, "compliant Deployments should not be annotated")
+}
+
+func cpuRequest(quantity string) corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(quantity)}}
+}
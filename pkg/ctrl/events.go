@@ -0,0 +1,96 @@
+package ctrl
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	// eventDedupWindow is how long an event is suppressed after an identical one was emitted.
+	eventDedupWindow = 10 * time.Minute
+	// maxDedupEntries bounds the number of remembered events before expired ones are pruned.
+	maxDedupEntries = 1024
+)
+
+// Reasons of the Normal events recorded on Frontends. Warning events use the reason
+// of the Degraded condition.
+const (
+	eventReasonCreated        = "Created"
+	eventReasonUpdated        = "Updated"
+	eventReasonDriftCorrected = "DriftCorrected"
+	eventReasonDeleted        = "Deleted"
+	eventReasonReleased       = "Released"
+)
+
+type eventKey struct {
+	uid       types.UID
+	eventtype string
+	reason    string
+	message   string
+}
+
+// dedupRecorder drops events identical to one emitted for the same object within
+// eventDedupWindow, so that resyncs and retries do not flood the namespace with events.
+type dedupRecorder struct {
+	record.EventRecorder
+
+	mu   sync.Mutex
+	now  func() time.Time
+	seen map[eventKey]time.Time
+}
+
+func newDedupRecorder(recorder record.EventRecorder) *dedupRecorder {
+	return &dedupRecorder{
+		EventRecorder: recorder,
+		now:           time.Now,
+		seen:          map[eventKey]time.Time{},
+	}
+}
+
+func (d *dedupRecorder) Event(obj runtime.Object, eventtype, reason, message string) {
+	if d.shouldEmit(obj, eventtype, reason, message) {
+		d.EventRecorder.Event(obj, eventtype, reason, message)
+	}
+}
+
+func (d *dedupRecorder) Eventf(obj runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	d.Event(obj, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func (d *dedupRecorder) AnnotatedEventf(obj runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	message := fmt.Sprintf(messageFmt, args...)
+	if d.shouldEmit(obj, eventtype, reason, message) {
+		d.EventRecorder.AnnotatedEventf(obj, annotations, eventtype, reason, "%s", message)
+	}
+}
+
+func (d *dedupRecorder) shouldEmit(obj runtime.Object, eventtype, reason, message string) bool {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return true
+	}
+	key := eventKey{uid: accessor.GetUID(), eventtype: eventtype, reason: reason, message: message}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	if last, ok := d.seen[key]; ok && now.Sub(last) < eventDedupWindow {
+		return false
+	}
+	if len(d.seen) >= maxDedupEntries {
+		for k, last := range d.seen {
+			if now.Sub(last) >= eventDedupWindow {
+				delete(d.seen, k)
+			}
+		}
+	}
+	d.seen[key] = now
+	return true
+}
@@ -0,0 +1,52 @@
+package ctrl
+
+import (
+	context "context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestDedupRecorder(t *testing.T) {
+	fake := record.NewFakeRecorder(10)
+	rec := newDedupRecorder(fake)
+	now := time.Now()
+	rec.now = func() time.Time { return now }
+
+	page := testFrontend()
+	page.UID = "frontend-uid"
+	other := testFrontend()
+	other.UID = "other-uid"
+
+	rec.Event(page, corev1.EventTypeWarning, "InvalidSpec", "bad image")
+	rec.Eventf(page, corev1.EventTypeWarning, "InvalidSpec", "bad %s", "image")
+	rec.Event(other, corev1.EventTypeWarning, "InvalidSpec", "bad image")
+	rec.Event(page, corev1.EventTypeWarning, "InvalidSpec", "bad replicas")
+	require.Len(t, fake.Events, 3, "identical events for the same object should be dropped")
+
+	now = now.Add(eventDedupWindow)
+	rec.Event(page, corev1.EventTypeWarning, "InvalidSpec", "bad image")
+	require.Len(t, fake.Events, 4, "events should be emitted again after the window")
+}
+
+func TestFrontendReconciler_WarningEvent(t *testing.T) {
+	page := testFrontend()
+	page.Spec.Image = "nginx: alpine"
+	r := newFakeReconciler(t, page)
+	fake := record.NewFakeRecorder(10)
+	r.Recorder = newDedupRecorder(fake)
+
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(page)}
+	for i := 0; i < 3; i++ {
+		_, err := r.Reconcile(context.Background(), req)
+		require.NoError(t, err)
+	}
+
+	require.Len(t, fake.Events, 1, "repeated failures should be reported once")
+	require.Contains(t, <-fake.Events, "Warning InvalidSpec")
+}
@@ -2,43 +2,108 @@ package ctrl
 
 import (
 	context "context"
-	"reflect"
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
 
 	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
 
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
 
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
-	frontendv1alpha1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1alpha1"
+	frontendv1beta1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
 )
 
+// fieldManager is the server-side apply field manager owning the Frontend child objects.
+const fieldManager = "frontend-controller"
+
+// contentRevisionAnnotation is stamped onto the pod template so that content changes roll the Deployment.
+const contentRevisionAnnotation = "frontend.oleksandr-san.io/content-revision"
+
 type FrontendReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	Options  FrontendOptions
 }
 
-func buildConfigMap(page *frontendv1alpha1.Frontend) *corev1.ConfigMap {
-	return &corev1.ConfigMap{
+// buildConfigMap renders the inline files of the Frontend and the content resolved from spec.contentsFrom
+// into an immutable ConfigMap named after the content revision.
+func buildConfigMap(page *frontendv1beta1.Frontend, sourced map[string][]byte) *corev1.ConfigMap {
+	cm := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      page.Name,
 			Namespace: page.Namespace,
 		},
-		Data: map[string]string{
-			"contents": page.Spec.Contents,
-		},
+		Immutable: ptrTo(true),
+		Data:      map[string]string{},
 	}
+	if len(page.Spec.Files) == 0 && len(page.Spec.ContentsFrom) == 0 {
+		cm.Data[legacyContentsKey] = ""
+	}
+	setBinary := func(key string, data []byte) {
+		if cm.BinaryData == nil {
+			cm.BinaryData = map[string][]byte{}
+		}
+		cm.BinaryData[key] = data
+	}
+	for key, file := range page.Spec.Files {
+		if len(file.BinaryContent) > 0 {
+			setBinary(key, file.BinaryContent)
+		} else {
+			cm.Data[key] = file.Content
+		}
+	}
+	for key, data := range sourced {
+		if utf8.Valid(data) {
+			cm.Data[key] = string(data)
+		} else {
+			setBinary(key, data)
+		}
+	}
+	if items := volumeItems(page); items != nil {
+		raw, _ := json.Marshal(items)
+		cm.Annotations = map[string]string{itemsAnnotation: string(raw)}
+	}
+
+	revision := contentRevision(cm)
+	cm.Name = revisionName(page, revision)
+	cm.Labels = map[string]string{frontendLabel: page.Name, revisionLabel: revision}
+	return cm
 }
 
-func buildDeployment(page *frontendv1alpha1.Frontend) *appsv1.Deployment {
-	replicas := int32(page.Spec.Replicas)
-	return &appsv1.Deployment{
+func buildDeployment(page *frontendv1beta1.Frontend, revision string) *appsv1.Deployment {
+	replicas := int32(defaultReplicas)
+	if page.Spec.Replicas != nil {
+		replicas = *page.Spec.Replicas
+	}
+	var podAnnotations map[string]string
+	if page.Spec.ContentUpdatePolicy != frontendv1beta1.ContentUpdateInPlace {
+		podAnnotations = map[string]string{contentRevisionAnnotation: revision}
+	}
+	dep := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      page.Name,
 			Namespace: page.Namespace,
@@ -50,24 +115,31 @@ func buildDeployment(page *frontendv1alpha1.Frontend) *appsv1.Deployment {
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: map[string]string{"app": page.Name},
+					Labels:      map[string]string{"app": page.Name, frontendLabel: page.Name},
+					Annotations: podAnnotations,
 				},
 				Spec: corev1.PodSpec{
 					Containers: []corev1.Container{{
 						Name:  "frontend",
 						Image: page.Spec.Image,
+						Ports: []corev1.ContainerPort{{
+							Name:          httpPortName,
+							ContainerPort: containerPort(page),
+							Protocol:      corev1.ProtocolTCP,
+						}},
 						VolumeMounts: []corev1.VolumeMount{{
-							Name:      "contents",
-							MountPath: "/data",
+							Name:      contentsVolumeName,
+							MountPath: mountPath(page),
 						}},
 					}},
 					Volumes: []corev1.Volume{{
-						Name: "contents",
+						Name: contentsVolumeName,
 						VolumeSource: corev1.VolumeSource{
 							ConfigMap: &corev1.ConfigMapVolumeSource{
 								LocalObjectReference: corev1.LocalObjectReference{
-									Name: page.Name,
+									Name: contentConfigMapName(page, revision),
 								},
+								Items: volumeItems(page),
 							},
 						},
 					}},
@@ -75,103 +147,390 @@ func buildDeployment(page *frontendv1alpha1.Frontend) *appsv1.Deployment {
 			},
 		},
 	}
+	applyPodTemplate(page, &dep.Spec.Template)
+	return dep
 }
 
 func (r *FrontendReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	var page frontendv1alpha1.Frontend
-	err := r.Get(ctx, req.NamespacedName, &page)
-	if err != nil {
-		if client.IgnoreNotFound(err) == nil {
-			// Frontend deleted: clean up resources
-			log.Info().Msgf("Frontend deleted: %s %s", req.Name, req.Namespace)
-			var cm corev1.ConfigMap
-			cm.Name = req.Name
-			cm.Namespace = req.Namespace
-			_ = r.Delete(ctx, &cm) // ignore errors if not found
-			var dep appsv1.Deployment
-			dep.Name = req.Name
-			dep.Namespace = req.Namespace
-			_ = r.Delete(ctx, &dep)
+	summary := &reconcileSummary{start: time.Now()}
+	if r.Options.ReconcileTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Options.ReconcileTimeout)
+		defer cancel()
+	}
+	result, err := r.reconcile(withReconcileSummary(ctx, summary), req)
+	// Conflicts and transient errors are retried soon, without growing the backoff of the Frontend
+	if delay, ok := r.Options.retryAfter(err); ok {
+		summary.retried = err
+		result, err = ctrl.Result{RequeueAfter: delay}, nil
+	}
+	summary.log(req, result, err)
+	return result, err
+}
+
+func (r *FrontendReconciler) reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var page frontendv1beta1.Frontend
+	if err := r.Get(ctx, req.NamespacedName, &page); err != nil {
+		if errors.IsNotFound(err) {
+			// Owned objects of Frontends deleted without the finalizer are garbage collected
+			forgetFrontend(req.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 		return ctrl.Result{}, err
 	}
 
-	// 1. Ensure ConfigMap exists and is up to date
-	cm := buildConfigMap(&page)
-	if err := ctrl.SetControllerReference(&page, cm, r.Scheme); err != nil {
-		return ctrl.Result{}, err
+	if !page.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, &page)
 	}
-
-	log.Info().Msgf("Reconciling ConfigMap for Frontend: %s %s", cm.Name, cm.Namespace)
-	var existingCM corev1.ConfigMap
-
-	if err := r.Get(ctx, req.NamespacedName, &existingCM); err != nil {
-		if !errors.IsNotFound(err) {
+	if controllerutil.AddFinalizer(&page, frontendFinalizer) {
+		if err := r.Update(ctx, &page); err != nil {
 			return ctrl.Result{}, err
 		}
+	}
 
-		if err := r.Create(ctx, cm); err != nil {
-			return ctrl.Result{}, err
-		}
-	} else if !reflect.DeepEqual(existingCM.Data, cm.Data) {
-		existingCM.Data = cm.Data
-		if err := r.Update(ctx, &existingCM); err != nil {
-			return ctrl.Result{}, err
+	if reason := r.pauseReason(&page); reason != "" {
+		return r.reconcilePaused(ctx, &page, reason)
+	}
+
+	applied, err := r.reconcileResources(ctx, &page)
+	if errors.IsConflict(err) {
+		// Retry with the latest version
+		return ctrl.Result{}, err
+	}
+
+	status := computeStatus(&page, applied.deployment, applied.revision, err)
+	if err == nil {
+		setRolloutStatus(&status, applied.rollout, page.Generation)
+	}
+	if degraded := meta.FindStatusCondition(status.Conditions, frontendv1beta1.ConditionDegraded); degraded.Status == metav1.ConditionTrue {
+		r.Recorder.Event(&page, corev1.EventTypeWarning, degraded.Reason, degraded.Message)
+	}
+	if statusErr := r.updateStatus(ctx, &page, status); statusErr != nil {
+		if err != nil {
+			log.Error().Err(statusErr).Msgf("Failed to update Frontend status: %s %s", page.Name, page.Namespace)
+		} else {
+			return ctrl.Result{}, statusErr
 		}
 	}
 
-	// 2. Ensure Deployment exists and is up to date
-	dep := buildDeployment(&page)
-	if err := ctrl.SetControllerReference(&page, dep, r.Scheme); err != nil {
+	var specErr invalidSpecError
+	if goerrors.As(err, &specErr) {
+		// Retrying will not help, wait for the spec to change
+		log.Error().Err(err).Msgf("Invalid Frontend spec: %s %s", page.Name, page.Namespace)
+		return ctrl.Result{}, nil
+	}
+	var sourceErr missingSourceError
+	if goerrors.As(err, &sourceErr) {
+		// The source is watched, wait for it to be created
+		log.Info().Err(err).Msgf("Waiting for content source of Frontend: %s %s", page.Name, page.Namespace)
+		return ctrl.Result{}, nil
+	}
+
+	if err != nil {
 		return ctrl.Result{}, err
 	}
+	recordReconciled(req.NamespacedName)
+	return ctrl.Result{RequeueAfter: applied.requeueAfter}, nil
+}
 
-	log.Info().Msgf("Reconciling Deployment for Frontend: %s %s", dep.Name, dep.Namespace)
-	var existingDep appsv1.Deployment
+// appliedResources describes the child objects applied for a Frontend.
+type appliedResources struct {
+	// deployment is the applied stable Deployment, nil if it was not applied.
+	deployment *appsv1.Deployment
+	// revision is the content revision served by the stable Deployment.
+	revision string
+	// rollout is the state of a BlueGreen or Canary rollout, nil for RollingUpdate.
+	rollout *frontendv1beta1.RolloutStatus
+	// requeueAfter schedules the next step of the rollout.
+	requeueAfter time.Duration
+}
 
-	if err := r.Get(ctx, req.NamespacedName, &existingDep); err != nil {
-		if !errors.IsNotFound(err) {
-			return ctrl.Result{}, err
-		}
+// reconcileResources applies the desired child objects of the Frontend.
+func (r *FrontendReconciler) reconcileResources(ctx context.Context, page *frontendv1beta1.Frontend) (appliedResources, error) {
+	var applied appliedResources
 
-		if err := r.Create(ctx, dep); err != nil {
-			return ctrl.Result{}, err
+	// Frontends admitted without the defaulting webhook may lack defaults
+	annotated := page
+	page = page.DeepCopy()
+	defaultFrontend(page)
+
+	// 1. Render the content revision, or look up the retained one to roll back to
+	if errs := validateFrontend(page); len(errs) > 0 {
+		return applied, invalidSpecError{errs.ToAggregate()}
+	}
+	var cm *corev1.ConfigMap
+	var revision string
+	if page.Spec.RollbackTo != "" {
+		var err error
+		if cm, err = r.getRevision(ctx, page, page.Spec.RollbackTo); err != nil {
+			return applied, err
 		}
+		revision = page.Spec.RollbackTo
+		applied.revision = revision
+		recordContentSize(page, contentSize(cm))
 	} else {
-		updated := false
+		sourced, err := r.resolveContentSources(ctx, page)
+		if err != nil {
+			return applied, err
+		}
+		cm = buildConfigMap(page, sourced)
+		revision = contentRevision(cm)
+		applied.revision = revision
+		recordContentSize(page, contentSize(cm))
+		if err := validateContentSize(cm); err != nil {
+			return applied, invalidSpecError{err}
+		}
+		if err := ctrl.SetControllerReference(page, cm, r.Scheme); err != nil {
+			return applied, err
+		}
+	}
+
+	// Changes to child objects are either caused by the Frontend or corrected drift
+	specChanged := page.Generation != page.Status.ObservedGeneration || page.Status.ContentRevision != revision
+
+	// 2. Plan the rollout of the content
+	dep := buildDeployment(page, revision)
+	if err := ctrl.SetControllerReference(page, dep, r.Scheme); err != nil {
+		return applied, err
+	}
+	existingDep, err := r.getDeployment(ctx, client.ObjectKeyFromObject(dep))
+	if err != nil {
+		return applied, err
+	}
+	// The replicas scaled by the autoscaler of the Frontend are taken back once it is disabled
+	reclaim, err := r.ownsAutoscaler(ctx, page)
+	if err != nil {
+		return applied, err
+	}
+	dep.Spec.Replicas = deploymentReplicas(page, existingDep, reclaim && page.Spec.Autoscaling == nil)
 
-		if *existingDep.Spec.Replicas != *dep.Spec.Replicas {
-			existingDep.Spec.Replicas = dep.Spec.Replicas
-			updated = true
+	plan := rolloutPlan{stableRevision: revision}
+	var canaryDep *appsv1.Deployment
+	if rolloutStrategy(page) != frontendv1beta1.RolloutRollingUpdate {
+		canaryDep, err = r.getDeployment(ctx, client.ObjectKey{Name: canaryName(page), Namespace: page.Namespace})
+		if err != nil {
+			return applied, err
 		}
+		total := int32(defaultReplicas)
+		if dep.Spec.Replicas != nil {
+			total = *dep.Spec.Replicas
+		} else if existingDep != nil && existingDep.Spec.Replicas != nil {
+			total = *existingDep.Spec.Replicas
+		}
+		plan = planRollout(page, revision, total, existingDep, canaryDep, time.Now())
+		applied.rollout = &plan.status
+		applied.requeueAfter = plan.requeueAfter
+		if plan.stableReplicas != nil && dep.Spec.Replicas != nil {
+			dep.Spec.Replicas = plan.stableReplicas
+		}
+	}
+	if page.Spec.Suspend {
+		// Only the pods are removed, so that resuming restores the Frontend as it was
+		dep.Spec.Replicas = ptrTo(int32(0))
+		plan.canaryReplicas = 0
+		plan.switched = false
+		applied.requeueAfter = 0
+	}
+	applied.revision = plan.stableRevision
+
+	// 3. Store the content revision and apply the stable Deployment, which keeps its content
+	// during a rollout
+	if page.Spec.RollbackTo == "" {
+		if err := r.applyOwned(ctx, page, cm, specChanged); err != nil {
+			return applied, err
+		}
+	}
+	content := cm
+	if page.Spec.ContentUpdatePolicy == frontendv1beta1.ContentUpdateInPlace {
+		content = currentConfigMap(page, cm)
+		if err := ctrl.SetControllerReference(page, content, r.Scheme); err != nil {
+			return applied, err
+		}
+		if err := r.applyOwned(ctx, page, content, specChanged); err != nil {
+			return applied, err
+		}
+	}
+	setContentVolume(dep, content)
+	if plan.stableRevision != revision {
+		frozenDeployment(dep, existingDep, plan.stableRevision)
+	}
+
+	if err := r.applyOwned(ctx, page, dep, specChanged); err != nil {
+		return applied, err
+	}
+	applied.deployment = dep
+
+	// 4. Apply the Deployment running the new content next to the stable one
+	if err := r.reconcileCanary(ctx, page, cm, revision, plan, specChanged); err != nil {
+		return applied, err
+	}
+
+	// 5. Apply the HorizontalPodAutoscaler after the Deployment, so that its replicas are
+	// reclaimed before the autoscaler is deleted
+	if err := r.reconcileAutoscaling(ctx, page, specChanged); err != nil {
+		return applied, err
+	}
+
+	// 6. Apply the Service and Ingress
+	if err := r.reconcileNetworking(ctx, page, plan.serviceSelector(page), specChanged); err != nil {
+		return applied, err
+	}
+
+	// 7. Apply the PodDisruptionBudget and NetworkPolicy
+	if err := r.reconcilePolicies(ctx, page, specChanged); err != nil {
+		return applied, err
+	}
 
-		if existingDep.Spec.Template.Spec.Containers[0].Image != dep.Spec.Template.Spec.Containers[0].Image {
-			existingDep.Spec.Template.Spec.Containers[0].Image = dep.Spec.Template.Spec.Containers[0].Image
-			updated = true
+	// 8. Garbage collect the content revisions beyond the history limit, keeping the ones
+	// still mounted by the pods
+	inUse := sets.New(cm.Name, contentConfigMapOf(dep), contentConfigMapOf(existingDep), contentConfigMapOf(canaryDep))
+	if err := r.pruneRevisions(ctx, page, inUse); err != nil {
+		return applied, err
+	}
+
+	if plan.actionHandled {
+		if err := r.clearRolloutAction(ctx, annotated); err != nil {
+			return applied, err
 		}
+	}
+	return applied, nil
+}
+
+// getDeployment returns the Deployment with the given key, or nil if it does not exist.
+func (r *FrontendReconciler) getDeployment(ctx context.Context, key client.ObjectKey) (*appsv1.Deployment, error) {
+	dep := &appsv1.Deployment{}
+	if err := r.Get(ctx, key, dep); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	return dep, nil
+}
 
-		if updated {
-			if err := r.Update(ctx, &existingDep); err != nil {
-				if errors.IsConflict(err) {
-					// Requeue to try again with the latest version
-					return ctrl.Result{Requeue: true}, nil
-				}
-				return ctrl.Result{}, err
-			}
+// ownershipConflictError is returned when a child object of the Frontend already exists but
+// is not controlled by it, e.g. a Service created by hand.
+type ownershipConflictError struct {
+	error
+}
+
+func (e ownershipConflictError) Unwrap() error { return e.error }
+
+// apply server-side applies obj, taking over any fields in it that were changed by other managers.
+func (r *FrontendReconciler) apply(ctx context.Context, obj client.Object) error {
+	return r.Patch(ctx, obj, client.Apply, client.FieldOwner(fieldManager), client.ForceOwnership)
+}
+
+// applyOwned applies an object owned by the Frontend and records an event on the Frontend
+// if the object was created or changed. specChanged tells spec updates apart from drift.
+func (r *FrontendReconciler) applyOwned(ctx context.Context, page *frontendv1beta1.Frontend, obj client.Object, specChanged bool) error {
+	existing := obj.DeepCopyObject().(client.Object)
+	err := r.Get(ctx, client.ObjectKeyFromObject(obj), existing)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	created := errors.IsNotFound(err)
+	if !created && !metav1.IsControlledBy(existing, page) {
+		// Forcing the ownership of the fields would silently take over the object
+		return ownershipConflictError{fmt.Errorf("%s %s already exists and is not controlled by the Frontend", r.kindOf(obj), obj.GetName())}
+	}
+
+	summary := summaryFrom(ctx)
+	obj.SetAnnotations(mergeMaps(obj.GetAnnotations(), map[string]string{specHashAnnotation: objectHash(obj)}))
+	if !created && upToDate(obj, existing) {
+		// Skip the request to the API server
+		summary.unchanged++
+		return nil
+	}
+	var drifted []string
+	if !created && !specChanged {
+		drifted = driftedFields(obj, existing)
+	}
+
+	if err := r.apply(ctx, obj); err != nil {
+		return err
+	}
+
+	kind := r.kindOf(obj)
+	switch {
+	case created:
+		summary.changed("Created", kind, obj.GetName())
+		r.Recorder.Eventf(page, corev1.EventTypeNormal, eventReasonCreated, "Created %s %s", kind, obj.GetName())
+	case obj.GetResourceVersion() == existing.GetResourceVersion():
+		summary.unchanged++
+	case specChanged:
+		summary.changed("Updated", kind, obj.GetName())
+		r.Recorder.Eventf(page, corev1.EventTypeNormal, eventReasonUpdated, "Updated %s %s", kind, obj.GetName())
+	default:
+		summary.changed("Reverted", kind, obj.GetName())
+		recordDriftCorrection(kind, drifted)
+		r.Recorder.Eventf(page, corev1.EventTypeNormal, eventReasonDriftCorrected, "Reverted changes made to %s %s by others", kind, obj.GetName())
+	}
+	return nil
+}
+
+// kindOf returns the kind of obj for use in events and log messages.
+func (r *FrontendReconciler) kindOf(obj client.Object) string {
+	gvk, err := apiutil.GVKForObject(obj, r.Scheme)
+	if err != nil {
+		return fmt.Sprintf("%T", obj)
+	}
+	return gvk.Kind
+}
+
+// replicasManagedExternally reports whether spec.replicas of the Deployment is owned by
+// another manager that scaled it through the scale subresource (HPA, kubectl scale).
+func replicasManagedExternally(dep *appsv1.Deployment) bool {
+	for _, mf := range dep.ManagedFields {
+		if mf.Manager == fieldManager || mf.Subresource != "scale" || mf.FieldsV1 == nil {
+			continue
+		}
+		var fields struct {
+			Spec map[string]json.RawMessage `json:"f:spec"`
+		}
+		if err := json.Unmarshal(mf.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+		if _, ok := fields.Spec["f:replicas"]; ok {
+			return true
 		}
 	}
+	return false
+}
 
-	return ctrl.Result{}, nil
+func init() {
+	RegisterController(Controller{
+		Name:             "frontend",
+		EnabledByDefault: true,
+		Setup: func(mgr manager.Manager, config *viper.Viper) error {
+			return AddFrontendController(mgr, frontendOptionsFromConfig(config))
+		},
+	})
 }
 
-func AddFrontendController(mgr manager.Manager) error {
+func AddFrontendController(mgr manager.Manager, opts FrontendOptions) error {
+	if err := indexContentSources(context.Background(), mgr); err != nil {
+		return err
+	}
+
+	r := &FrontendReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: newDedupRecorder(mgr.GetEventRecorderFor(fieldManager)),
+		Options:  opts.withDefaults(),
+	}
+	r.Options.record()
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&frontendv1alpha1.Frontend{}).
-		Owns(&appsv1.Deployment{}).
+		WithOptions(r.Options.controllerOptions()).
+		For(&frontendv1beta1.Frontend{}, builder.WithPredicates(frontendChanged)).
+		Owns(&appsv1.Deployment{}, builder.WithPredicates(deploymentChanged)).
 		Owns(&corev1.ConfigMap{}).
-		Complete(&FrontendReconciler{
-			Client: mgr.GetClient(),
-			Scheme: mgr.GetScheme(),
-		})
+		Owns(&corev1.Service{}).
+		Owns(&networkingv1.Ingress{}).
+		// Only spec changes of objects with frequently updated statuses can be drift
+		Owns(&autoscalingv2.HorizontalPodAutoscaler{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Owns(&policyv1.PodDisruptionBudget{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Owns(&networkingv1.NetworkPolicy{}).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.frontendsReferencing(configMapSourceIndex))).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.frontendsReferencing(secretSourceIndex))).
+		Watches(&frontendv1beta1.Frontend{}, handler.EnqueueRequestsFromMapFunc(r.frontendsReferencing(frontendSourceIndex)),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
 }
@@ -0,0 +1,126 @@
+package ctrl
+
+import (
+	context "context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	frontendv1beta1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
+)
+
+// defaultTargetCPUUtilization is used for autoscaled Frontends that do not set any target.
+const defaultTargetCPUUtilization = 80
+
+func buildAutoscaler(page *frontendv1beta1.Frontend) *autoscalingv2.HorizontalPodAutoscaler {
+	spec := page.Spec.Autoscaling
+
+	resourceMetric := func(name corev1.ResourceName, utilization int32) autoscalingv2.MetricSpec {
+		return autoscalingv2.MetricSpec{
+			Type: autoscalingv2.ResourceMetricSourceType,
+			Resource: &autoscalingv2.ResourceMetricSource{
+				Name: name,
+				Target: autoscalingv2.MetricTarget{
+					Type:               autoscalingv2.UtilizationMetricType,
+					AverageUtilization: &utilization,
+				},
+			},
+		}
+	}
+	var metrics []autoscalingv2.MetricSpec
+	if spec.TargetCPUUtilizationPercentage != nil {
+		metrics = append(metrics, resourceMetric(corev1.ResourceCPU, *spec.TargetCPUUtilizationPercentage))
+	}
+	if spec.TargetMemoryUtilizationPercentage != nil {
+		metrics = append(metrics, resourceMetric(corev1.ResourceMemory, *spec.TargetMemoryUtilizationPercentage))
+	}
+	if len(metrics) == 0 {
+		metrics = append(metrics, resourceMetric(corev1.ResourceCPU, defaultTargetCPUUtilization))
+	}
+
+	return &autoscalingv2.HorizontalPodAutoscaler{
+		TypeMeta: metav1.TypeMeta{APIVersion: "autoscaling/v2", Kind: "HorizontalPodAutoscaler"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      page.Name,
+			Namespace: page.Namespace,
+		},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       page.Name,
+			},
+			MinReplicas: spec.MinReplicas,
+			MaxReplicas: spec.MaxReplicas,
+			Metrics:     metrics,
+		},
+	}
+}
+
+// deploymentReplicas returns the replicas to apply to the Deployment of the Frontend, or nil to
+// leave them to the autoscaler. existing is the current Deployment, nil if it does not exist yet.
+// reclaim takes the replicas back from an autoscaler of the Frontend that has been disabled.
+func deploymentReplicas(page *frontendv1beta1.Frontend, existing *appsv1.Deployment, reclaim bool) *int32 {
+	replicas := int32(defaultReplicas)
+	if page.Spec.Replicas != nil {
+		replicas = *page.Spec.Replicas
+	}
+
+	spec := page.Spec.Autoscaling
+	if spec == nil {
+		if existing != nil && !reclaim && replicasManagedExternally(existing) {
+			// Leave the replica count to whoever scales the Deployment (e.g. an HPA)
+			return nil
+		}
+		return &replicas
+	}
+
+	if existing != nil {
+		if replicasManagedExternally(existing) {
+			return nil
+		}
+		// Until the autoscaler has scaled the Deployment, keep its current replica count:
+		// dropping the field from the applied configuration would reset it to 1
+		if existing.Spec.Replicas != nil {
+			replicas = *existing.Spec.Replicas
+		}
+	}
+	minReplicas := int32(1)
+	if spec.MinReplicas != nil {
+		minReplicas = *spec.MinReplicas
+	}
+	return ptrTo(min(max(replicas, minReplicas), spec.MaxReplicas))
+}
+
+// ownsAutoscaler reports whether the Frontend controls an existing HorizontalPodAutoscaler.
+func (r *FrontendReconciler) ownsAutoscaler(ctx context.Context, page *frontendv1beta1.Frontend) (bool, error) {
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(page), hpa); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	return metav1.IsControlledBy(hpa, page), nil
+}
+
+// reconcileAutoscaling applies the HorizontalPodAutoscaler of the Frontend, or deletes it
+// if autoscaling is disabled.
+func (r *FrontendReconciler) reconcileAutoscaling(ctx context.Context, page *frontendv1beta1.Frontend, specChanged bool) error {
+	if page.Spec.Autoscaling == nil {
+		return r.deleteOwned(ctx, page, &autoscalingv2.HorizontalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: page.Name, Namespace: page.Namespace},
+		})
+	}
+
+	hpa := buildAutoscaler(page)
+	if err := ctrl.SetControllerReference(page, hpa, r.Scheme); err != nil {
+		return err
+	}
+
+	return r.applyOwned(ctx, page, hpa, specChanged)
+}
+
+func ptrTo[T any](v T) *T { return &v }
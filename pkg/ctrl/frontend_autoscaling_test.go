@@ -0,0 +1,117 @@
+package ctrl
+
+import (
+	context "context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	frontendv1beta1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
+	testutil "github.com/oleksandr-san/k8s-controller/pkg/testutil"
+)
+
+func TestBuildAutoscaler(t *testing.T) {
+	page := testFrontend()
+	page.Spec.Autoscaling = &frontendv1beta1.AutoscalingSpec{MinReplicas: int32Ptr(2), MaxReplicas: 10}
+
+	hpa := buildAutoscaler(page)
+	require.Equal(t, "Deployment", hpa.Spec.ScaleTargetRef.Kind)
+	require.Equal(t, page.Name, hpa.Spec.ScaleTargetRef.Name)
+	require.Equal(t, int32(2), *hpa.Spec.MinReplicas)
+	require.Equal(t, int32(10), hpa.Spec.MaxReplicas)
+	require.Len(t, hpa.Spec.Metrics, 1)
+	require.Equal(t, corev1.ResourceCPU, hpa.Spec.Metrics[0].Resource.Name)
+	require.Equal(t, int32(defaultTargetCPUUtilization), *hpa.Spec.Metrics[0].Resource.Target.AverageUtilization)
+
+	page.Spec.Autoscaling.TargetMemoryUtilizationPercentage = int32Ptr(70)
+	hpa = buildAutoscaler(page)
+	require.Len(t, hpa.Spec.Metrics, 1, "the default CPU target only applies without explicit targets")
+	require.Equal(t, corev1.ResourceMemory, hpa.Spec.Metrics[0].Resource.Name)
+	require.Equal(t, int32(70), *hpa.Spec.Metrics[0].Resource.Target.AverageUtilization)
+}
+
+func TestDeploymentReplicas(t *testing.T) {
+	page := testFrontend()
+	scaled := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{ManagedFields: []metav1.ManagedFieldsEntry{{
+			Manager:     "kube-controller-manager",
+			Operation:   metav1.ManagedFieldsOperationUpdate,
+			Subresource: "scale",
+			FieldsV1:    &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:replicas":{}}}`)},
+		}}},
+		Spec: appsv1.DeploymentSpec{Replicas: int32Ptr(7)},
+	}
+	current := &appsv1.Deployment{Spec: appsv1.DeploymentSpec{Replicas: int32Ptr(12)}}
+
+	require.Equal(t, int32Ptr(2), deploymentReplicas(page, nil, false))
+	require.Equal(t, int32Ptr(2), deploymentReplicas(page, current, false))
+	require.Nil(t, deploymentReplicas(page, scaled, false), "externally scaled replicas should be left alone")
+	require.Equal(t, int32Ptr(2), deploymentReplicas(page, scaled, true), "replicas should be reclaimed from a disabled autoscaler")
+
+	page.Spec.Autoscaling = &frontendv1beta1.AutoscalingSpec{MinReplicas: int32Ptr(3), MaxReplicas: 10}
+	require.Equal(t, int32Ptr(3), deploymentReplicas(page, nil, false), "new Deployments should start within the autoscaling range")
+	require.Equal(t, int32Ptr(10), deploymentReplicas(page, current, false), "current replicas should be kept within the autoscaling range")
+	require.Nil(t, deploymentReplicas(page, scaled, false), "replicas should be left to the autoscaler")
+}
+
+func TestFrontendReconciler_Autoscaling(t *testing.T) {
+	mgr, k8sClient, _, cleanup := testutil.StartTestManager(t)
+	defer cleanup()
+
+	require.NoError(t, AddFrontendController(mgr, DefaultFrontendOptions()))
+
+	ctx := context.Background()
+	page := &frontendv1beta1.Frontend{
+		ObjectMeta: metav1.ObjectMeta{Name: "autoscaled-page", Namespace: "default"},
+		Spec: frontendv1beta1.FrontendSpec{
+			Files:       map[string]frontendv1beta1.FrontendFile{"contents": {Content: "hello world"}},
+			Image:       "nginx:alpine",
+			Replicas:    int32Ptr(1),
+			Autoscaling: &frontendv1beta1.AutoscalingSpec{MinReplicas: int32Ptr(2), MaxReplicas: 5},
+		},
+	}
+	require.NoError(t, k8sClient.Create(ctx, page))
+
+	key := client.ObjectKeyFromObject(page)
+	var hpa autoscalingv2.HorizontalPodAutoscaler
+	var dep appsv1.Deployment
+	require.Eventually(t, func() bool {
+		return k8sClient.Get(ctx, key, &hpa) == nil && k8sClient.Get(ctx, key, &dep) == nil
+	}, 10*time.Second, 100*time.Millisecond, "HorizontalPodAutoscaler and Deployment should be created")
+	require.True(t, metav1.IsControlledBy(&hpa, page))
+	require.Equal(t, int32(2), *dep.Spec.Replicas, "Deployment should start at minReplicas")
+
+	// Scaling by the autoscaler is not reverted
+	scale := &autoscalingv1.Scale{}
+	require.NoError(t, k8sClient.SubResource("scale").Get(ctx, &dep, scale))
+	scale.Spec.Replicas = 4
+	require.NoError(t, k8sClient.SubResource("scale").Update(ctx, &dep, client.WithSubResourceBody(scale)))
+
+	require.NoError(t, k8sClient.Get(ctx, key, page))
+	page.Spec.Files["contents"] = frontendv1beta1.FrontendFile{Content: "updated"}
+	require.NoError(t, k8sClient.Update(ctx, page))
+	require.Eventually(t, func() bool {
+		if err := k8sClient.Get(ctx, key, page); err != nil {
+			return false
+		}
+		return page.Status.ObservedGeneration == page.Generation
+	}, 10*time.Second, 100*time.Millisecond, "update should be reconciled")
+	require.NoError(t, k8sClient.Get(ctx, key, &dep))
+	require.Equal(t, int32(4), *dep.Spec.Replicas, "replicas should be left to the autoscaler")
+
+	// Disabling autoscaling deletes the autoscaler and restores spec.replicas
+	page.Spec.Autoscaling = nil
+	require.NoError(t, k8sClient.Update(ctx, page))
+	require.Eventually(t, func() bool {
+		return errors.IsNotFound(k8sClient.Get(ctx, key, &hpa)) &&
+			k8sClient.Get(ctx, key, &dep) == nil && *dep.Spec.Replicas == 1
+	}, 10*time.Second, 100*time.Millisecond, "HorizontalPodAutoscaler should be deleted and replicas reclaimed")
+}
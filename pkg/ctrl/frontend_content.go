@@ -0,0 +1,168 @@
+package ctrl
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	frontendv1beta1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
+)
+
+const (
+	// legacyContentsKey is the file v1alpha1 serves spec.contents as. Frontends
+	// without any files keep serving it empty.
+	legacyContentsKey = "contents"
+	defaultMountPath  = "/data"
+
+	// maxContentSize is the maximum size of the data stored in a ConfigMap.
+	maxContentSize = 1024 * 1024
+)
+
+// invalidSpecError marks errors caused by the Frontend spec itself. They are reported
+// in the status but not retried, as only a spec change can fix them.
+type invalidSpecError struct {
+	error
+}
+
+func (e invalidSpecError) Unwrap() error { return e.error }
+
+func mountPath(page *frontendv1beta1.Frontend) string {
+	if page.Spec.MountPath == "" {
+		return defaultMountPath
+	}
+	return page.Spec.MountPath
+}
+
+// volumeItems maps the ConfigMap keys to their paths in the mount. It returns nil
+// when every key is mounted under its own name.
+func volumeItems(page *frontendv1beta1.Frontend) []corev1.KeyToPath {
+	var items []corev1.KeyToPath
+	for _, key := range slices.Sorted(maps.Keys(page.Spec.Files)) {
+		p := page.Spec.Files[key].Path
+		if p == "" {
+			p = key
+		}
+		items = append(items, corev1.KeyToPath{Key: key, Path: p})
+	}
+	for _, src := range page.Spec.ContentsFrom {
+		key, p := sourceKey(src), src.Path
+		if p == "" {
+			p = key
+		}
+		items = append(items, corev1.KeyToPath{Key: key, Path: p})
+	}
+
+	for _, item := range items {
+		if item.Key != item.Path {
+			return items
+		}
+	}
+	return nil
+}
+
+// validateContent checks the files and content sources of the Frontend can be rendered into a ConfigMap.
+func validateContent(page *frontendv1beta1.Frontend) field.ErrorList {
+	var errs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	if mp := page.Spec.MountPath; mp != "" && !path.IsAbs(mp) {
+		errs = append(errs, field.Invalid(specPath.Child("mountPath"), mp, "must be an absolute path"))
+	}
+
+	paths := map[string]string{}
+
+	filesPath := specPath.Child("files")
+	for _, key := range slices.Sorted(maps.Keys(page.Spec.Files)) {
+		file := page.Spec.Files[key]
+		keyPath := filesPath.Key(key)
+
+		for _, msg := range validation.IsConfigMapKey(key) {
+			errs = append(errs, field.Invalid(keyPath, key, msg))
+		}
+		if file.Content != "" && len(file.BinaryContent) > 0 {
+			errs = append(errs, field.Invalid(keyPath, key, "content and binaryContent are mutually exclusive"))
+		}
+
+		p := file.Path
+		if p == "" {
+			p = key
+		}
+		if msg := validateFilePath(p); msg != "" {
+			errs = append(errs, field.Invalid(keyPath.Child("path"), file.Path, msg))
+		} else if other, ok := paths[p]; ok {
+			errs = append(errs, field.Invalid(keyPath.Child("path"), p, fmt.Sprintf("path is already used by file %q", other)))
+		}
+		paths[p] = key
+	}
+
+	if srcErrs := validateContentSources(page); len(srcErrs) > 0 {
+		return append(errs, srcErrs...)
+	}
+
+	keys := map[string]bool{}
+	for key := range page.Spec.Files {
+		keys[key] = true
+	}
+	sourcesPath := specPath.Child("contentsFrom")
+	for i, src := range page.Spec.ContentsFrom {
+		key := sourceKey(src)
+		if keys[key] {
+			errs = append(errs, field.Duplicate(sourcesPath.Index(i), key))
+			continue
+		}
+		keys[key] = true
+
+		p := src.Path
+		if p == "" {
+			p = key
+		}
+		if msg := validateFilePath(p); msg != "" {
+			errs = append(errs, field.Invalid(sourcesPath.Index(i).Child("path"), src.Path, msg))
+		} else if other, ok := paths[p]; ok {
+			errs = append(errs, field.Invalid(sourcesPath.Index(i).Child("path"), p, fmt.Sprintf("path is already used by file %q", other)))
+		}
+		paths[p] = key
+	}
+
+	return errs
+}
+
+// validateFilePath returns an error message if p is not a valid path within a volume.
+func validateFilePath(p string) string {
+	if path.IsAbs(p) {
+		return "must be a relative path"
+	}
+	for _, elem := range strings.Split(p, "/") {
+		if elem == ".." {
+			return "must not contain '..'"
+		}
+	}
+	return ""
+}
+
+// contentSize returns the number of bytes the data of cm takes up.
+func contentSize(cm *corev1.ConfigMap) int {
+	size := 0
+	for k, v := range cm.Data {
+		size += len(k) + len(v)
+	}
+	for k, v := range cm.BinaryData {
+		size += len(k) + len(v)
+	}
+	return size
+}
+
+// validateContentSize checks the rendered content fits into a single ConfigMap.
+func validateContentSize(cm *corev1.ConfigMap) *field.Error {
+	if size := contentSize(cm); size > maxContentSize {
+		return field.Invalid(field.NewPath("spec", "files"), size,
+			fmt.Sprintf("total content size of %d bytes exceeds the ConfigMap limit of %d bytes", size, maxContentSize))
+	}
+	return nil
+}
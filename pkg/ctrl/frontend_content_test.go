@@ -0,0 +1,93 @@
+package ctrl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	frontendv1beta1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
+)
+
+func TestBuildConfigMap_Files(t *testing.T) {
+	page := testFrontend()
+	page.Spec.Files = map[string]frontendv1beta1.FrontendFile{
+		"index.html": {Content: "<h1>hello</h1>"},
+		"site.css":   {Path: "css/site.css", Content: "h1 { color: red }"},
+		"logo.png":   {Path: "img/logo.png", BinaryContent: []byte{0x89, 'P', 'N', 'G'}},
+	}
+	page.Spec.MountPath = "/usr/share/nginx/html"
+	require.Empty(t, validateContent(page))
+
+	cm := buildConfigMap(page, nil)
+	require.Equal(t, map[string]string{
+		"index.html": "<h1>hello</h1>",
+		"site.css":   "h1 { color: red }",
+	}, cm.Data)
+	require.Equal(t, map[string][]byte{"logo.png": {0x89, 'P', 'N', 'G'}}, cm.BinaryData)
+
+	dep := buildDeployment(page, contentRevision(cm))
+	require.Equal(t, "/usr/share/nginx/html", dep.Spec.Template.Spec.Containers[0].VolumeMounts[0].MountPath)
+	require.Equal(t, []corev1.KeyToPath{
+		{Key: "index.html", Path: "index.html"},
+		{Key: "logo.png", Path: "img/logo.png"},
+		{Key: "site.css", Path: "css/site.css"},
+	}, dep.Spec.Template.Spec.Volumes[0].ConfigMap.Items)
+
+	page.Spec.Files["logo.png"] = frontendv1beta1.FrontendFile{Path: "img/logo.png", BinaryContent: []byte{0x89, 'P', 'N', 'G', 0}}
+	require.NotEqual(t, contentRevision(cm), contentRevision(buildConfigMap(page, nil)), "binary changes should change the revision")
+}
+
+func TestBuildConfigMap_LegacyContents(t *testing.T) {
+	page := testFrontend()
+
+	cm := buildConfigMap(page, nil)
+	require.Equal(t, map[string]string{"contents": "hello world"}, cm.Data)
+	require.Nil(t, cm.BinaryData)
+
+	dep := buildDeployment(page, contentRevision(cm))
+	require.Equal(t, "/data", dep.Spec.Template.Spec.Containers[0].VolumeMounts[0].MountPath)
+	require.Nil(t, dep.Spec.Template.Spec.Volumes[0].ConfigMap.Items)
+
+	// Frontends without any files serve an empty contents file
+	page.Spec.Files = nil
+	require.Equal(t, map[string]string{"contents": ""}, buildConfigMap(page, nil).Data)
+}
+
+func TestValidateContent(t *testing.T) {
+	page := testFrontend()
+	page.Spec.MountPath = "data"
+	page.Spec.Files = map[string]frontendv1beta1.FrontendFile{
+		"bad/key":    {Content: "x"},
+		"escape":     {Path: "../etc/passwd", Content: "x"},
+		"both":       {Content: "x", BinaryContent: []byte("y")},
+		"index.html": {Content: "x"},
+		"dup":        {Path: "index.html", Content: "x"},
+	}
+
+	errs := validateContent(page)
+	var fields []string
+	for _, err := range errs {
+		fields = append(fields, err.Field)
+	}
+	require.ElementsMatch(t, []string{
+		"spec.mountPath",
+		"spec.files[bad/key]",
+		"spec.files[escape].path",
+		"spec.files[both]",
+		"spec.files[index.html].path",
+	}, fields)
+}
+
+func TestValidateContentSize(t *testing.T) {
+	page := testFrontend()
+	page.Spec.Files["contents"] = frontendv1beta1.FrontendFile{Content: strings.Repeat("x", maxContentSize)}
+
+	err := validateContentSize(buildConfigMap(page, nil))
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "exceeds the ConfigMap limit")
+
+	page.Spec.Files["contents"] = frontendv1beta1.FrontendFile{Content: strings.Repeat("x", 1024)}
+	require.Nil(t, validateContentSize(buildConfigMap(page, nil)))
+}
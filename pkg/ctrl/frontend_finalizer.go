@@ -0,0 +1,142 @@
+package ctrl
+
+import (
+	context "context"
+	goerrors "errors"
+	"fmt"
+	"slices"
+
+	"github.com/rs/zerolog/log"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	frontendv1beta1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
+)
+
+// frontendFinalizer blocks the deletion of a Frontend until its owned objects
+// have been cleaned up according to spec.deletionPolicy.
+const frontendFinalizer = "frontend.oleksandr-san.io/cleanup"
+
+// cleanupError is returned when the owned objects of a deleted Frontend could not be cleaned up.
+type cleanupError struct {
+	error
+}
+
+func (e cleanupError) Unwrap() error { return e.error }
+
+// ownedObjects returns the objects the controller may have created for the Frontend, apart
+// from the ConfigMaps of its content revisions.
+func ownedObjects(page *frontendv1beta1.Frontend) []client.Object {
+	meta := func(name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: page.Namespace}
+	}
+	return []client.Object{
+		&autoscalingv2.HorizontalPodAutoscaler{ObjectMeta: meta(page.Name)},
+		&policyv1.PodDisruptionBudget{ObjectMeta: meta(page.Name)},
+		&networkingv1.NetworkPolicy{ObjectMeta: meta(page.Name)},
+		&networkingv1.Ingress{ObjectMeta: meta(page.Name)},
+		&corev1.Service{ObjectMeta: meta(page.Name)},
+		&appsv1.Deployment{ObjectMeta: meta(canaryName(page))},
+		&appsv1.Deployment{ObjectMeta: meta(page.Name)},
+		&corev1.ConfigMap{ObjectMeta: meta(page.Name)},
+	}
+}
+
+// reconcileDelete cleans up the owned objects of a deleted Frontend and removes the finalizer.
+func (r *FrontendReconciler) reconcileDelete(ctx context.Context, page *frontendv1beta1.Frontend) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(page, frontendFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	log.Info().Msgf("Cleaning up Frontend: %s %s (deletion policy %s)", page.Name, page.Namespace, deletionPolicy(page))
+	if err := r.finalize(ctx, page); err != nil {
+		var dep *appsv1.Deployment
+		existing := &appsv1.Deployment{}
+		if r.Get(ctx, client.ObjectKeyFromObject(page), existing) == nil {
+			dep = existing
+		}
+		status := computeStatus(page, dep, page.Status.ContentRevision, err)
+		if statusErr := r.updateStatus(ctx, page, status); statusErr != nil {
+			log.Error().Err(statusErr).Msgf("Failed to update Frontend status: %s %s", page.Name, page.Namespace)
+		}
+		return ctrl.Result{}, err
+	}
+
+	controllerutil.RemoveFinalizer(page, frontendFinalizer)
+	if err := r.Update(ctx, page); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	return ctrl.Result{}, nil
+}
+
+// finalize deletes or releases the owned objects of the Frontend according to its deletion policy.
+// Objects that are not controlled by the Frontend are never touched.
+func (r *FrontendReconciler) finalize(ctx context.Context, page *frontendv1beta1.Frontend) error {
+	policy := deletionPolicy(page)
+
+	objs := ownedObjects(page)
+	revisions, err := r.listRevisions(ctx, page)
+	if err != nil {
+		return cleanupError{err}
+	}
+	for i := range revisions {
+		objs = append(objs, &revisions[i])
+	}
+
+	var errs []error
+	for _, obj := range objs {
+		_, isConfigMap := obj.(*corev1.ConfigMap)
+
+		var err error
+		if policy == frontendv1beta1.DeletionOrphan || (policy == frontendv1beta1.DeletionRetain && isConfigMap) {
+			err = r.releaseOwned(ctx, page, obj)
+		} else {
+			err = r.deleteOwned(ctx, page, obj)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%T %s: %w", obj, obj.GetName(), err))
+		}
+	}
+	if len(errs) > 0 {
+		return cleanupError{goerrors.Join(errs...)}
+	}
+	return nil
+}
+
+// releaseOwned removes the owner reference to the Frontend from obj if it exists and is
+// controlled by the Frontend, so that it is not garbage collected together with it.
+func (r *FrontendReconciler) releaseOwned(ctx context.Context, page *frontendv1beta1.Frontend, obj client.Object) error {
+	if err := r.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !metav1.IsControlledBy(obj, page) {
+		return nil
+	}
+
+	patch := client.MergeFromWithOptions(obj.DeepCopyObject().(client.Object), client.MergeFromWithOptimisticLock{})
+	obj.SetOwnerReferences(slices.DeleteFunc(obj.GetOwnerReferences(), func(ref metav1.OwnerReference) bool {
+		return ref.UID == page.UID
+	}))
+	if err := r.Patch(ctx, obj, patch); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	summaryFrom(ctx).changed("Released", r.kindOf(obj), obj.GetName())
+	r.Recorder.Eventf(page, corev1.EventTypeNormal, eventReasonReleased, "Released %s %s", r.kindOf(obj), obj.GetName())
+	return nil
+}
+
+func deletionPolicy(page *frontendv1beta1.Frontend) frontendv1beta1.DeletionPolicy {
+	if page.Spec.DeletionPolicy == "" {
+		return frontendv1beta1.DeletionDelete
+	}
+	return page.Spec.DeletionPolicy
+}
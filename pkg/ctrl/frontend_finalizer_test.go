@@ -0,0 +1,170 @@
+package ctrl
+
+import (
+	context "context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	frontendv1beta1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
+	testutil "github.com/oleksandr-san/k8s-controller/pkg/testutil"
+)
+
+// deletedFrontend returns a Frontend pending deletion together with its owned objects
+// and an Ingress of the same name it does not own.
+func deletedFrontend(policy frontendv1beta1.DeletionPolicy) (*frontendv1beta1.Frontend, []client.Object) {
+	page := testFrontend()
+	page.UID = types.UID("frontend-uid")
+	page.Spec.DeletionPolicy = policy
+	page.Finalizers = []string{frontendFinalizer}
+	now := metav1.Now()
+	page.DeletionTimestamp = &now
+
+	owner := []metav1.OwnerReference{{
+		APIVersion: frontendv1beta1.SchemeGroupVersion.String(),
+		Kind:       "Frontend",
+		Name:       page.Name,
+		UID:        page.UID,
+		Controller: ptrTo(true),
+	}}
+	meta := metav1.ObjectMeta{Name: page.Name, Namespace: page.Namespace, OwnerReferences: owner}
+	return page, []client.Object{
+		page,
+		&corev1.ConfigMap{ObjectMeta: *meta.DeepCopy()},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Name:            revisionName(page, "0123456789abcdef"),
+			Namespace:       page.Namespace,
+			Labels:          map[string]string{frontendLabel: page.Name, revisionLabel: "0123456789abcdef"},
+			OwnerReferences: owner,
+		}},
+		&appsv1.Deployment{ObjectMeta: *meta.DeepCopy()},
+		&corev1.Service{ObjectMeta: *meta.DeepCopy()},
+		&autoscalingv2.HorizontalPodAutoscaler{ObjectMeta: *meta.DeepCopy()},
+		&policyv1.PodDisruptionBudget{ObjectMeta: *meta.DeepCopy()},
+		&networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: page.Name, Namespace: page.Namespace}},
+	}
+}
+
+func TestFrontendReconciler_Finalize(t *testing.T) {
+	tests := []struct {
+		policy frontendv1beta1.DeletionPolicy
+		kept   []string
+	}{
+		{policy: frontendv1beta1.DeletionDelete, kept: nil},
+		{policy: frontendv1beta1.DeletionOrphan, kept: []string{"ConfigMap", "Deployment", "HorizontalPodAutoscaler", "PodDisruptionBudget", "Service"}},
+		{policy: frontendv1beta1.DeletionRetain, kept: []string{"ConfigMap"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			page, objs := deletedFrontend(tt.policy)
+			r := newFakeReconciler(t, objs...)
+			ctx := context.Background()
+
+			_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(page)})
+			require.NoError(t, err)
+			require.True(t, errors.IsNotFound(r.Get(ctx, client.ObjectKeyFromObject(page), &frontendv1beta1.Frontend{})),
+				"Frontend should be gone once the finalizer is removed")
+
+			key := client.ObjectKeyFromObject(page)
+			owned := map[string]client.Object{
+				"ConfigMap":               &corev1.ConfigMap{},
+				"Deployment":              &appsv1.Deployment{},
+				"Service":                 &corev1.Service{},
+				"HorizontalPodAutoscaler": &autoscalingv2.HorizontalPodAutoscaler{},
+				"PodDisruptionBudget":     &policyv1.PodDisruptionBudget{},
+			}
+			for kind, obj := range owned {
+				err := r.Get(ctx, key, obj)
+				if contains(tt.kept, kind) {
+					require.NoError(t, err, "%s should be kept", kind)
+					require.Empty(t, obj.GetOwnerReferences(), "%s should be released", kind)
+				} else {
+					require.True(t, errors.IsNotFound(err), "%s should be deleted", kind)
+				}
+			}
+			revisionKey := client.ObjectKey{Name: revisionName(page, "0123456789abcdef"), Namespace: page.Namespace}
+			err = r.Get(ctx, revisionKey, &corev1.ConfigMap{})
+			require.Equal(t, contains(tt.kept, "ConfigMap"), err == nil, "content revisions should be handled like the ConfigMap")
+			require.NoError(t, r.Get(ctx, key, &networkingv1.Ingress{}), "objects not owned by the Frontend must not be touched")
+		})
+	}
+}
+
+func TestFrontendReconciler_FinalizeFailure(t *testing.T) {
+	page, objs := deletedFrontend(frontendv1beta1.DeletionDelete)
+	r := newFakeReconciler(t, objs...)
+	r.Client = interceptor.NewClient(r.Client.(client.WithWatch), interceptor.Funcs{
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			if _, ok := obj.(*appsv1.Deployment); ok {
+				return fmt.Errorf("deletion refused")
+			}
+			return c.Delete(ctx, obj, opts...)
+		},
+	})
+	ctx := context.Background()
+
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(page)})
+	require.ErrorContains(t, err, "deletion refused")
+
+	var got frontendv1beta1.Frontend
+	require.NoError(t, r.Get(ctx, client.ObjectKeyFromObject(page), &got))
+	require.Contains(t, got.Finalizers, frontendFinalizer, "finalizer should be kept until cleanup succeeds")
+	requireCondition(t, got.Status, frontendv1beta1.ConditionDegraded, metav1.ConditionTrue, "CleanupFailed")
+}
+
+func TestFrontendReconciler_OrphanOnDelete(t *testing.T) {
+	mgr, k8sClient, _, cleanup := testutil.StartTestManager(t)
+	defer cleanup()
+
+	require.NoError(t, AddFrontendController(mgr, DefaultFrontendOptions()))
+
+	ctx := context.Background()
+	page := &frontendv1beta1.Frontend{
+		ObjectMeta: metav1.ObjectMeta{Name: "orphan-page", Namespace: "default"},
+		Spec: frontendv1beta1.FrontendSpec{
+			Files:          map[string]frontendv1beta1.FrontendFile{"contents": {Content: "hello world"}},
+			Image:          "nginx:alpine",
+			DeletionPolicy: frontendv1beta1.DeletionOrphan,
+		},
+	}
+	require.NoError(t, k8sClient.Create(ctx, page))
+
+	key := client.ObjectKeyFromObject(page)
+	var dep appsv1.Deployment
+	require.Eventually(t, func() bool {
+		var got frontendv1beta1.Frontend
+		return k8sClient.Get(ctx, key, &got) == nil && contains(got.Finalizers, frontendFinalizer) &&
+			k8sClient.Get(ctx, key, &dep) == nil
+	}, 10*time.Second, 100*time.Millisecond, "finalizer and Deployment should be added")
+
+	require.NoError(t, k8sClient.Delete(ctx, page))
+	require.Eventually(t, func() bool {
+		return errors.IsNotFound(k8sClient.Get(ctx, key, &frontendv1beta1.Frontend{}))
+	}, 10*time.Second, 100*time.Millisecond, "Frontend should be deleted")
+
+	require.NoError(t, k8sClient.Get(ctx, key, &dep))
+	require.Empty(t, dep.OwnerReferences, "Deployment should be released")
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,208 @@
+package ctrl
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	frontendv1beta1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
+)
+
+var (
+	frontendsByPhase = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "frontend_frontends",
+		Help: "Number of Frontends by namespace and phase.",
+	}, []string{"namespace", "phase"})
+	frontendDriftCorrections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "frontend_drift_corrections_total",
+		Help: "Number of child objects reverted after others changed fields applied by the controller.",
+	}, []string{"kind", "field"})
+	frontendContentSize = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "frontend_content_size_bytes",
+		Help: "Size of the content served by a Frontend.",
+	}, []string{"namespace", "frontend"})
+	frontendRolloutDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "frontend_rollout_duration_seconds",
+		Help:    "Time from the start of a rollout until the Frontend stops progressing.",
+		Buckets: prometheus.ExponentialBuckets(5, 2, 10),
+	}, []string{"strategy"})
+	frontendChildOperations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "frontend_child_object_operations_total",
+		Help: "Number of child objects of Frontends created, updated and deleted by the controller.",
+	}, []string{"kind", "operation"})
+	frontendLastReconcile = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "frontend_last_successful_reconcile_timestamp_seconds",
+		Help: "Unix time of the last successful reconcile of a Frontend.",
+	}, []string{"namespace", "frontend"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		frontendsByPhase,
+		frontendDriftCorrections,
+		frontendContentSize,
+		frontendRolloutDuration,
+		frontendChildOperations,
+		frontendLastReconcile,
+	)
+}
+
+// Phases of a Frontend as reported by the frontend_frontends metric.
+const (
+	phaseDeleting    = "Deleting"
+	phasePaused      = "Paused"
+	phaseDegraded    = "Degraded"
+	phaseSuspended   = "Suspended"
+	phaseProgressing = "Progressing"
+	phaseReady       = "Ready"
+	phaseNotReady    = "NotReady"
+)
+
+// frontendPhase summarizes the status of a Frontend into a single phase.
+func frontendPhase(page *frontendv1beta1.Frontend, status frontendv1beta1.FrontendStatus) string {
+	switch {
+	case !page.DeletionTimestamp.IsZero():
+		return phaseDeleting
+	case meta.IsStatusConditionTrue(status.Conditions, frontendv1beta1.ConditionPaused):
+		return phasePaused
+	case meta.IsStatusConditionTrue(status.Conditions, frontendv1beta1.ConditionDegraded):
+		return phaseDegraded
+	case page.Spec.Suspend:
+		return phaseSuspended
+	case meta.IsStatusConditionTrue(status.Conditions, frontendv1beta1.ConditionProgressing):
+		return phaseProgressing
+	case meta.IsStatusConditionTrue(status.Conditions, frontendv1beta1.ConditionReady):
+		return phaseReady
+	}
+	return phaseNotReady
+}
+
+// phaseTracker remembers the phase of each Frontend, so that frontend_frontends counts every
+// Frontend in exactly one phase.
+type phaseTracker struct {
+	mu     sync.Mutex
+	phases map[types.NamespacedName]string
+}
+
+var frontendPhases = &phaseTracker{phases: map[types.NamespacedName]string{}}
+
+func (t *phaseTracker) set(key types.NamespacedName, phase string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if old, ok := t.phases[key]; ok {
+		if old == phase {
+			return
+		}
+		frontendsByPhase.WithLabelValues(key.Namespace, old).Dec()
+	}
+	t.phases[key] = phase
+	frontendsByPhase.WithLabelValues(key.Namespace, phase).Inc()
+}
+
+func (t *phaseTracker) forget(key types.NamespacedName) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if old, ok := t.phases[key]; ok {
+		frontendsByPhase.WithLabelValues(key.Namespace, old).Dec()
+		delete(t.phases, key)
+	}
+}
+
+// recordStatus updates the metrics derived from the status of the Frontend. previous is the
+// status the Frontend had before, nil if it did not change.
+func recordStatus(page *frontendv1beta1.Frontend, previous *frontendv1beta1.FrontendStatus, status frontendv1beta1.FrontendStatus) {
+	frontendPhases.set(client.ObjectKeyFromObject(page), frontendPhase(page, status))
+	if previous == nil {
+		return
+	}
+	started := meta.FindStatusCondition(previous.Conditions, frontendv1beta1.ConditionProgressing)
+	if started == nil || started.Status != metav1.ConditionTrue ||
+		meta.IsStatusConditionTrue(status.Conditions, frontendv1beta1.ConditionProgressing) {
+		return
+	}
+	frontendRolloutDuration.WithLabelValues(string(rolloutStrategy(page))).
+		Observe(time.Since(started.LastTransitionTime.Time).Seconds())
+}
+
+// recordContentSize records the size of the content served by the Frontend.
+func recordContentSize(page *frontendv1beta1.Frontend, size int) {
+	frontendContentSize.WithLabelValues(page.Namespace, page.Name).Set(float64(size))
+}
+
+// recordReconciled records a successful reconcile of the Frontend.
+func recordReconciled(key types.NamespacedName) {
+	frontendLastReconcile.WithLabelValues(key.Namespace, key.Name).SetToCurrentTime()
+}
+
+// forgetFrontend removes the metrics of a Frontend that no longer exists.
+func forgetFrontend(key types.NamespacedName) {
+	frontendPhases.forget(key)
+	frontendContentSize.DeleteLabelValues(key.Namespace, key.Name)
+	frontendLastReconcile.DeleteLabelValues(key.Namespace, key.Name)
+}
+
+// childOperations maps the changes of the reconcile summary to the operations they count as.
+var childOperations = map[string]string{
+	"Created":  "create",
+	"Updated":  "update",
+	"Reverted": "update",
+	"Released": "update",
+	"Deleted":  "delete",
+}
+
+func recordChildOperation(action, kind string) {
+	if operation, ok := childOperations[action]; ok {
+		frontendChildOperations.WithLabelValues(kind, operation).Inc()
+	}
+}
+
+func recordDriftCorrection(kind string, fields []string) {
+	for _, field := range fields {
+		frontendDriftCorrections.WithLabelValues(kind, field).Inc()
+	}
+}
+
+// maxDriftDepth limits the drifted fields to paths like spec.template.spec.containers.
+const maxDriftDepth = 4
+
+// opaqueFields hold user-defined keys, which are not reported as separate fields.
+var opaqueFields = sets.New("labels", "annotations", "matchLabels", "nodeSelector", "data", "binaryData")
+
+// diffFields returns the paths of the fields set in desired that have another value in
+// existing, down to maxDriftDepth.
+func diffFields(desired, existing any, path string, depth int) []string {
+	if isSubset(desired, existing) {
+		return nil
+	}
+	d, ok := desired.(map[string]any)
+	e, eok := existing.(map[string]any)
+	if path != "" && (!ok || !eok || depth == 0) {
+		return []string{path}
+	}
+	var fields []string
+	for _, key := range slices.Sorted(maps.Keys(d)) {
+		fieldPath := key
+		if path != "" {
+			fieldPath = path + "." + key
+		}
+		if opaqueFields.Has(key) {
+			if !isSubset(d[key], e[key]) {
+				fields = append(fields, fieldPath)
+			}
+			continue
+		}
+		fields = append(fields, diffFields(d[key], e[key], fieldPath, depth-1)...)
+	}
+	return fields
+}
@@ -0,0 +1,159 @@
+package ctrl
+
+import (
+	context "context"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	frontendv1beta1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
+	k8stestutil "github.com/oleksandr-san/k8s-controller/pkg/testutil"
+)
+
+func TestFrontendPhase(t *testing.T) {
+	page := testFrontend()
+	status := computeStatus(page, nil, "abc", nil)
+	require.Equal(t, phaseProgressing, frontendPhase(page, status))
+
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{Type: frontendv1beta1.ConditionProgressing, Status: metav1.ConditionFalse, Reason: "RolloutComplete"})
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{Type: frontendv1beta1.ConditionReady, Status: metav1.ConditionTrue, Reason: "DeploymentReady"})
+	require.Equal(t, phaseReady, frontendPhase(page, status))
+
+	page.Spec.Suspend = true
+	require.Equal(t, phaseSuspended, frontendPhase(page, status))
+
+	page.Spec.Paused = true
+	require.Equal(t, phasePaused, frontendPhase(page, computeStatus(page, nil, "abc", nil)))
+
+	page.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	require.Equal(t, phaseDeleting, frontendPhase(page, status))
+}
+
+func TestPhaseTracker(t *testing.T) {
+	tracker := &phaseTracker{phases: map[types.NamespacedName]string{}}
+	ns := "phase-tracker"
+	a := types.NamespacedName{Namespace: ns, Name: "a"}
+	b := types.NamespacedName{Namespace: ns, Name: "b"}
+
+	tracker.set(a, phaseProgressing)
+	tracker.set(b, phaseProgressing)
+	tracker.set(a, phaseReady)
+	tracker.set(a, phaseReady)
+	require.Equal(t, 1.0, testutil.ToFloat64(frontendsByPhase.WithLabelValues(ns, phaseProgressing)))
+	require.Equal(t, 1.0, testutil.ToFloat64(frontendsByPhase.WithLabelValues(ns, phaseReady)))
+
+	tracker.forget(b)
+	tracker.forget(b)
+	require.Equal(t, 0.0, testutil.ToFloat64(frontendsByPhase.WithLabelValues(ns, phaseProgressing)))
+}
+
+func TestDriftedFields(t *testing.T) {
+	page := testFrontend()
+	page.Spec.PodTemplate = nil
+	desired := buildDeployment(page, "abc")
+	desired.SetAnnotations(map[string]string{specHashAnnotation: objectHash(desired)})
+
+	existing := desired.DeepCopy()
+	existing.Status.ReadyReplicas = 2
+	require.Empty(t, driftedFields(desired, existing))
+
+	existing.Spec.Replicas = int32Ptr(5)
+	existing.Spec.Template.Spec.Containers[0].Image = "nginx:latest"
+	existing.Spec.Template.Labels["app"] = "other"
+	require.Equal(t, []string{
+		"spec.replicas",
+		"spec.template.metadata.labels",
+		"spec.template.spec.containers",
+	}, driftedFields(desired, existing))
+}
+
+func TestFrontendReconciler_Metrics(t *testing.T) {
+	mgr, k8sClient, _, cleanup := k8stestutil.StartTestManager(t)
+	defer cleanup()
+
+	require.NoError(t, AddFrontendController(mgr, DefaultFrontendOptions()))
+
+	ctx := context.Background()
+	page := &frontendv1beta1.Frontend{
+		ObjectMeta: metav1.ObjectMeta{Name: "metrics-page", Namespace: "default"},
+		Spec: frontendv1beta1.FrontendSpec{
+			Files: map[string]frontendv1beta1.FrontendFile{"contents": {Content: "hello world"}},
+			Image: "nginx:alpine",
+		},
+	}
+	require.NoError(t, k8sClient.Create(ctx, page))
+	key := client.ObjectKeyFromObject(page)
+
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(frontendLastReconcile.WithLabelValues(page.Namespace, page.Name)) > 0
+	}, 10*time.Second, 100*time.Millisecond, "successful reconciles should be recorded")
+	require.Equal(t, float64(len("contents")+len("hello world")),
+		testutil.ToFloat64(frontendContentSize.WithLabelValues(page.Namespace, page.Name)))
+	require.GreaterOrEqual(t, testutil.ToFloat64(frontendChildOperations.WithLabelValues("Deployment", "create")), 1.0)
+
+	// Changes made by others to applied fields are reverted and counted by field
+	var dep appsv1.Deployment
+	require.NoError(t, k8sClient.Get(ctx, key, &dep))
+	dep.Spec.Template.Spec.Containers[0].Image = "nginx:latest"
+	require.NoError(t, k8sClient.Update(ctx, &dep))
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(frontendDriftCorrections.WithLabelValues("Deployment", "spec.template.spec.containers")) >= 1
+	}, 10*time.Second, 100*time.Millisecond, "drift corrections should be counted")
+
+	families, err := metrics.Registry.Gather()
+	require.NoError(t, err)
+	var names []string
+	for _, family := range families {
+		names = append(names, family.GetName())
+	}
+	for _, name := range []string{
+		"frontend_frontends",
+		"frontend_drift_corrections_total",
+		"frontend_content_size_bytes",
+		"frontend_child_object_operations_total",
+		"frontend_last_successful_reconcile_timestamp_seconds",
+	} {
+		require.Contains(t, names, name)
+	}
+
+	// The metrics of deleted Frontends are removed
+	require.NoError(t, k8sClient.Delete(ctx, page))
+	series := map[string]string{"namespace": page.Namespace, "frontend": page.Name}
+	require.Eventually(t, func() bool {
+		return !hasSeries(frontendLastReconcile, series)
+	}, 10*time.Second, 100*time.Millisecond, "metrics of deleted Frontends should be removed")
+	require.False(t, hasSeries(frontendContentSize, series))
+}
+
+// hasSeries reports whether c collects a metric with the given labels.
+func hasSeries(c prometheus.Collector, labels map[string]string) bool {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		c.Collect(ch)
+		close(ch)
+	}()
+	found := false
+	for m := range ch {
+		var metric dto.Metric
+		if m.Write(&metric) != nil {
+			continue
+		}
+		values := map[string]string{}
+		for _, label := range metric.GetLabel() {
+			values[label.GetName()] = label.GetValue()
+		}
+		found = found || maps.Equal(values, labels)
+	}
+	return found
+}
@@ -0,0 +1,167 @@
+package ctrl
+
+import (
+	context "context"
+	goerrors "errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/viper"
+	"golang.org/x/time/rate"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/workqueue"
+
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	defaultMaxConcurrentReconciles = 1
+	defaultBaseDelay               = 5 * time.Millisecond
+	defaultMaxDelay                = 5 * time.Minute
+	defaultQPS                     = 10
+	defaultBurst                   = 100
+	defaultReconcileTimeout        = 2 * time.Minute
+	defaultRetryDelay              = time.Second
+)
+
+// FrontendOptions configures how many Frontends are reconciled at once and how failed
+// reconciles are retried.
+type FrontendOptions struct {
+	// MaxConcurrentReconciles is the number of Frontends reconciled in parallel.
+	MaxConcurrentReconciles int
+	// BaseDelay and MaxDelay bound the exponential backoff of a Frontend whose reconciles
+	// keep failing.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// QPS and Burst limit the rate at which all Frontends are requeued together.
+	QPS   float64
+	Burst int
+	// ReconcileTimeout cancels reconciles that take longer, 0 disables it.
+	ReconcileTimeout time.Duration
+	// RetryDelay is how long to wait before retrying after a conflict or a transient API error.
+	RetryDelay time.Duration
+	// Paused stops reconciling the child objects of all Frontends, like spec.paused does for one.
+	Paused bool
+}
+
+// DefaultFrontendOptions returns the options used by the server command unless overridden.
+func DefaultFrontendOptions() FrontendOptions {
+	return FrontendOptions{
+		MaxConcurrentReconciles: defaultMaxConcurrentReconciles,
+		BaseDelay:               defaultBaseDelay,
+		MaxDelay:                defaultMaxDelay,
+		QPS:                     defaultQPS,
+		Burst:                   defaultBurst,
+		ReconcileTimeout:        defaultReconcileTimeout,
+		RetryDelay:              defaultRetryDelay,
+	}
+}
+
+// frontendOptionsFromConfig reads the options set in the controllers.frontend section of the
+// server config over the defaults.
+func frontendOptionsFromConfig(config *viper.Viper) FrontendOptions {
+	o := DefaultFrontendOptions()
+	if config.IsSet("max-concurrent-reconciles") {
+		o.MaxConcurrentReconciles = config.GetInt("max-concurrent-reconciles")
+	}
+	if config.IsSet("base-delay") {
+		o.BaseDelay = config.GetDuration("base-delay")
+	}
+	if config.IsSet("max-delay") {
+		o.MaxDelay = config.GetDuration("max-delay")
+	}
+	if config.IsSet("qps") {
+		o.QPS = config.GetFloat64("qps")
+	}
+	if config.IsSet("burst") {
+		o.Burst = config.GetInt("burst")
+	}
+	if config.IsSet("reconcile-timeout") {
+		o.ReconcileTimeout = config.GetDuration("reconcile-timeout")
+	}
+	if config.IsSet("retry-delay") {
+		o.RetryDelay = config.GetDuration("retry-delay")
+	}
+	o.Paused = config.GetBool("paused")
+	return o
+}
+
+// withDefaults fills in the unset options but ReconcileTimeout, for which 0 means no timeout.
+func (o FrontendOptions) withDefaults() FrontendOptions {
+	if o.MaxConcurrentReconciles <= 0 {
+		o.MaxConcurrentReconciles = defaultMaxConcurrentReconciles
+	}
+	if o.BaseDelay <= 0 {
+		o.BaseDelay = defaultBaseDelay
+	}
+	if o.MaxDelay < o.BaseDelay {
+		o.MaxDelay = max(defaultMaxDelay, o.BaseDelay)
+	}
+	if o.QPS <= 0 {
+		o.QPS = defaultQPS
+	}
+	if o.Burst <= 0 {
+		o.Burst = defaultBurst
+	}
+	if o.RetryDelay <= 0 {
+		o.RetryDelay = defaultRetryDelay
+	}
+	return o
+}
+
+// controllerOptions returns the options of the Frontend controller, with a rate limiter that
+// backs off each Frontend exponentially and limits the overall rate with a token bucket.
+func (o FrontendOptions) controllerOptions() controller.Options {
+	return controller.Options{
+		MaxConcurrentReconciles: o.MaxConcurrentReconciles,
+		RateLimiter: workqueue.NewTypedMaxOfRateLimiter(
+			workqueue.NewTypedItemExponentialFailureRateLimiter[reconcile.Request](o.BaseDelay, o.MaxDelay),
+			&workqueue.TypedBucketRateLimiter[reconcile.Request]{Limiter: rate.NewLimiter(rate.Limit(o.QPS), o.Burst)},
+		),
+	}
+}
+
+// retryAfter returns when to retry a reconcile that failed with err, or false if err is not
+// transient and the reconcile is retried with the backoff of the work queue.
+func (o FrontendOptions) retryAfter(err error) (time.Duration, bool) {
+	if err == nil {
+		return 0, false
+	}
+	if seconds, ok := errors.SuggestsClientDelay(err); ok && seconds > 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if errors.IsConflict(err) || errors.IsServerTimeout(err) || errors.IsTimeout(err) ||
+		errors.IsTooManyRequests(err) || errors.IsServiceUnavailable(err) ||
+		goerrors.Is(err, context.DeadlineExceeded) {
+		return o.withDefaults().RetryDelay, true
+	}
+	return 0, false
+}
+
+// frontendSettings exposes the options the Frontend controller runs with.
+var frontendSettings = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "frontend_controller_setting",
+	Help: "Setting of the Frontend controller, durations in seconds.",
+}, []string{"setting"})
+
+func init() {
+	metrics.Registry.MustRegister(frontendSettings)
+}
+
+func (o FrontendOptions) record() {
+	frontendSettings.WithLabelValues("max_concurrent_reconciles").Set(float64(o.MaxConcurrentReconciles))
+	frontendSettings.WithLabelValues("base_delay").Set(o.BaseDelay.Seconds())
+	frontendSettings.WithLabelValues("max_delay").Set(o.MaxDelay.Seconds())
+	frontendSettings.WithLabelValues("qps").Set(o.QPS)
+	frontendSettings.WithLabelValues("burst").Set(float64(o.Burst))
+	frontendSettings.WithLabelValues("reconcile_timeout").Set(o.ReconcileTimeout.Seconds())
+	frontendSettings.WithLabelValues("retry_delay").Set(o.RetryDelay.Seconds())
+	paused := 0.0
+	if o.Paused {
+		paused = 1
+	}
+	frontendSettings.WithLabelValues("paused").Set(paused)
+}
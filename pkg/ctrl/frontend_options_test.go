@@ -0,0 +1,61 @@
+package ctrl
+
+import (
+	context "context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestFrontendOptions_WithDefaults(t *testing.T) {
+	opts := FrontendOptions{MaxConcurrentReconciles: 4, BaseDelay: time.Second}.withDefaults()
+	require.Equal(t, 4, opts.MaxConcurrentReconciles)
+	require.Equal(t, time.Second, opts.BaseDelay)
+	require.Equal(t, defaultMaxDelay, opts.MaxDelay)
+	require.Equal(t, float64(defaultQPS), opts.QPS)
+	require.Equal(t, defaultBurst, opts.Burst)
+	require.Equal(t, defaultRetryDelay, opts.RetryDelay)
+	require.Zero(t, opts.ReconcileTimeout, "a zero timeout disables it")
+
+	// The backoff never ends before it starts
+	opts = FrontendOptions{BaseDelay: 10 * time.Minute, MaxDelay: time.Minute}.withDefaults()
+	require.Equal(t, 10*time.Minute, opts.MaxDelay)
+}
+
+func TestFrontendOptions_RetryAfter(t *testing.T) {
+	opts := FrontendOptions{RetryDelay: 3 * time.Second}
+	gr := schema.GroupResource{Group: "apps", Resource: "deployments"}
+
+	for _, tc := range []struct {
+		name  string
+		err   error
+		delay time.Duration
+		retry bool
+	}{
+		{name: "no error"},
+		{name: "conflict", err: errors.NewConflict(gr, "test-page", fmt.Errorf("modified")), delay: 3 * time.Second, retry: true},
+		{name: "throttled", err: errors.NewTooManyRequests("slow down", 7), delay: 7 * time.Second, retry: true},
+		{name: "unavailable", err: errors.NewServiceUnavailable("restarting"), delay: 3 * time.Second, retry: true},
+		{name: "timeout", err: fmt.Errorf("get deployment: %w", context.DeadlineExceeded), delay: 3 * time.Second, retry: true},
+		{name: "forbidden", err: errors.NewForbidden(gr, "test-page", fmt.Errorf("denied"))},
+		{name: "other", err: fmt.Errorf("boom")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			delay, retry := opts.retryAfter(tc.err)
+			require.Equal(t, tc.retry, retry)
+			require.Equal(t, tc.delay, delay)
+		})
+	}
+}
+
+func TestFrontendOptions_Record(t *testing.T) {
+	DefaultFrontendOptions().record()
+	require.Equal(t, float64(defaultMaxConcurrentReconciles), testutil.ToFloat64(frontendSettings.WithLabelValues("max_concurrent_reconciles")))
+	require.Equal(t, defaultReconcileTimeout.Seconds(), testutil.ToFloat64(frontendSettings.WithLabelValues("reconcile_timeout")))
+}
@@ -0,0 +1,83 @@
+package ctrl
+
+import (
+	context "context"
+
+	"github.com/rs/zerolog/log"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	frontendv1beta1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
+)
+
+// pausedAnnotation pauses a Frontend like spec.paused when set to "true", without changing
+// its spec.
+const pausedAnnotation = "frontend.oleksandr-san.io/paused"
+
+// pauseReason returns why the child objects of the Frontend are left untouched, or "" if
+// they are reconciled.
+func pauseReason(page *frontendv1beta1.Frontend) string {
+	switch {
+	case page.Spec.Paused:
+		return "SpecPaused"
+	case page.Annotations[pausedAnnotation] == "true":
+		return "PausedByAnnotation"
+	}
+	return ""
+}
+
+// pauseReason returns why the child objects of the Frontend are left untouched, including
+// when the controller pauses all Frontends, or "" if they are reconciled.
+func (r *FrontendReconciler) pauseReason(page *frontendv1beta1.Frontend) string {
+	if reason := pauseReason(page); reason != "" {
+		return reason
+	}
+	if r.Options.Paused {
+		return "PausedByController"
+	}
+	return ""
+}
+
+// setPausedCondition reports whether reconciling the child objects of the Frontend is paused.
+func setPausedCondition(status *frontendv1beta1.FrontendStatus, page *frontendv1beta1.Frontend) {
+	setPausedReason(status, page, pauseReason(page))
+}
+
+// setPausedReason sets the Paused condition with reason, or removes it if reason is "".
+func setPausedReason(status *frontendv1beta1.FrontendStatus, page *frontendv1beta1.Frontend, reason string) {
+	if reason == "" {
+		meta.RemoveStatusCondition(&status.Conditions, frontendv1beta1.ConditionPaused)
+		return
+	}
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               frontendv1beta1.ConditionPaused,
+		Status:             metav1.ConditionTrue,
+		Reason:             reason,
+		Message:            "Child objects are not reconciled",
+		ObservedGeneration: page.Generation,
+	})
+}
+
+// reconcilePaused updates the status of a paused Frontend from its Deployment without
+// changing any of its child objects.
+func (r *FrontendReconciler) reconcilePaused(ctx context.Context, page *frontendv1beta1.Frontend, reason string) (ctrl.Result, error) {
+	log.Debug().Msgf("Frontend is paused: %s %s", page.Name, page.Namespace)
+
+	dep, err := r.getDeployment(ctx, client.ObjectKeyFromObject(page))
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	status := computeStatus(page, dep, page.Status.ContentRevision, nil)
+	setPausedReason(&status, page, reason)
+	// Spec changes made while paused have not been applied yet
+	status.ObservedGeneration = page.Status.ObservedGeneration
+	if err := r.updateStatus(ctx, page, status); err != nil {
+		return ctrl.Result{}, err
+	}
+	recordReconciled(client.ObjectKeyFromObject(page))
+	return ctrl.Result{}, nil
+}
@@ -0,0 +1,117 @@
+package ctrl
+
+import (
+	context "context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	frontendv1beta1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
+	testutil "github.com/oleksandr-san/k8s-controller/pkg/testutil"
+)
+
+func TestComputeStatus_Suspended(t *testing.T) {
+	page := testFrontend()
+	page.Spec.Suspend = true
+	dep := buildDeployment(page, "abc")
+	dep.Spec.Replicas = int32Ptr(0)
+
+	status := computeStatus(page, dep, "abc", nil)
+	requireCondition(t, status, frontendv1beta1.ConditionReady, metav1.ConditionFalse, "Suspended")
+	require.Nil(t, meta.FindStatusCondition(status.Conditions, frontendv1beta1.ConditionPaused))
+
+	page.Annotations = map[string]string{pausedAnnotation: "true"}
+	status = computeStatus(page, dep, "abc", nil)
+	requireCondition(t, status, frontendv1beta1.ConditionPaused, metav1.ConditionTrue, "PausedByAnnotation")
+}
+
+func TestFrontendReconciler_Paused(t *testing.T) {
+	page := testFrontend()
+	page.Spec.Paused = true
+	page.Generation = 4
+	page.Status.ObservedGeneration = 3
+	page.Status.ContentRevision = "abc"
+
+	// The Deployment was edited by hand during an incident
+	dep := buildDeployment(page, "abc")
+	dep.Spec.Template.Spec.Containers[0].Image = "nginx:debug"
+	r := newFakeReconciler(t, page, dep)
+	ctx := context.Background()
+
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(page)})
+	require.NoError(t, err)
+
+	var got appsv1.Deployment
+	require.NoError(t, r.Get(ctx, client.ObjectKeyFromObject(page), &got))
+	require.Equal(t, "nginx:debug", got.Spec.Template.Spec.Containers[0].Image, "paused Frontends must not revert changes")
+
+	require.NoError(t, r.Get(ctx, client.ObjectKeyFromObject(page), page))
+	requireCondition(t, page.Status, frontendv1beta1.ConditionPaused, metav1.ConditionTrue, "SpecPaused")
+	require.Equal(t, int64(3), page.Status.ObservedGeneration, "spec changes made while paused are not applied")
+	require.Equal(t, "abc", page.Status.ContentRevision)
+}
+
+func TestFrontendReconciler_GloballyPaused(t *testing.T) {
+	page := testFrontend()
+	page.Status.ContentRevision = "abc"
+	dep := buildDeployment(page, "abc")
+	dep.Spec.Template.Spec.Containers[0].Image = "nginx:debug"
+	r := newFakeReconciler(t, page, dep)
+	r.Options.Paused = true
+	ctx := context.Background()
+	key := client.ObjectKeyFromObject(page)
+
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+
+	var got appsv1.Deployment
+	require.NoError(t, r.Get(ctx, key, &got))
+	require.Equal(t, "nginx:debug", got.Spec.Template.Spec.Containers[0].Image, "the controller pause applies to all Frontends")
+	require.NoError(t, r.Get(ctx, key, page))
+	requireCondition(t, page.Status, frontendv1beta1.ConditionPaused, metav1.ConditionTrue, "PausedByController")
+
+	// Resuming the controller resumes the Frontends that are not paused themselves
+	r.Options.Paused = false
+	require.Empty(t, r.pauseReason(page))
+	page.Annotations = map[string]string{pausedAnnotation: "true"}
+	require.Equal(t, "PausedByAnnotation", r.pauseReason(page))
+}
+
+func TestFrontendReconciler_Suspend(t *testing.T) {
+	mgr, k8sClient, _, cleanup := testutil.StartTestManager(t)
+	defer cleanup()
+
+	require.NoError(t, AddFrontendController(mgr, DefaultFrontendOptions()))
+
+	ctx := context.Background()
+	page := &frontendv1beta1.Frontend{
+		ObjectMeta: metav1.ObjectMeta{Name: "suspended-page", Namespace: "default"},
+		Spec: frontendv1beta1.FrontendSpec{
+			Files:    map[string]frontendv1beta1.FrontendFile{"contents": {Content: "hello world"}},
+			Image:    "nginx:alpine",
+			Replicas: int32Ptr(2),
+			Suspend:  true,
+		},
+	}
+	require.NoError(t, k8sClient.Create(ctx, page))
+
+	key := client.ObjectKeyFromObject(page)
+	var dep appsv1.Deployment
+	require.Eventually(t, func() bool {
+		return k8sClient.Get(ctx, key, &dep) == nil && dep.Spec.Replicas != nil && *dep.Spec.Replicas == 0
+	}, 10*time.Second, 100*time.Millisecond, "suspended Frontends should be scaled down to zero")
+
+	require.NoError(t, k8sClient.Get(ctx, key, page))
+	page.Spec.Suspend = false
+	require.NoError(t, k8sClient.Update(ctx, page))
+
+	require.Eventually(t, func() bool {
+		return k8sClient.Get(ctx, key, &dep) == nil && dep.Spec.Replicas != nil && *dep.Spec.Replicas == 2
+	}, 10*time.Second, 100*time.Millisecond, "resumed Frontends should be scaled back up")
+}
@@ -0,0 +1,95 @@
+package ctrl
+
+import (
+	"maps"
+
+	corev1 "k8s.io/api/core/v1"
+
+	frontendv1beta1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
+)
+
+const (
+	// tmpVolumeName is the writable scratch volume of the Frontend container, whose root
+	// filesystem is read-only by default.
+	tmpVolumeName = "tmp"
+	tmpMountPath  = "/tmp"
+)
+
+// applyPodTemplate merges spec.podTemplate and the secure defaults into the generated pod template.
+// The selector label and the content revision annotation cannot be overridden.
+func applyPodTemplate(page *frontendv1beta1.Frontend, tmpl *corev1.PodTemplateSpec) {
+	var overlay frontendv1beta1.PodTemplateSpec
+	if page.Spec.PodTemplate != nil {
+		overlay = *page.Spec.PodTemplate.DeepCopy()
+	}
+
+	tmpl.Labels = mergeMaps(overlay.Labels, tmpl.Labels)
+	tmpl.Annotations = mergeMaps(overlay.Annotations, tmpl.Annotations)
+
+	pod := &tmpl.Spec
+	pod.SecurityContext = podSecurityContext(overlay.PodSecurityContext)
+	pod.ImagePullSecrets = overlay.ImagePullSecrets
+	pod.NodeSelector = overlay.NodeSelector
+	pod.Affinity = overlay.Affinity
+	pod.Tolerations = overlay.Tolerations
+	pod.Volumes = append(pod.Volumes, corev1.Volume{
+		Name:         tmpVolumeName,
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	})
+
+	container := &pod.Containers[0]
+	container.SecurityContext = containerSecurityContext(overlay.SecurityContext)
+	if overlay.Resources != nil {
+		container.Resources = *overlay.Resources
+	}
+	container.LivenessProbe = overlay.LivenessProbe
+	container.ReadinessProbe = overlay.ReadinessProbe
+	container.Env = overlay.Env
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      tmpVolumeName,
+		MountPath: tmpMountPath,
+	})
+}
+
+// podSecurityContext returns sc with unset fields defaulted to run as non-root with the
+// runtime's default seccomp profile.
+func podSecurityContext(sc *corev1.PodSecurityContext) *corev1.PodSecurityContext {
+	if sc == nil {
+		sc = &corev1.PodSecurityContext{}
+	}
+	if sc.RunAsNonRoot == nil {
+		sc.RunAsNonRoot = ptrTo(true)
+	}
+	if sc.SeccompProfile == nil {
+		sc.SeccompProfile = &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}
+	}
+	return sc
+}
+
+// containerSecurityContext returns sc with unset fields defaulted to a read-only root
+// filesystem without privilege escalation or capabilities.
+func containerSecurityContext(sc *corev1.SecurityContext) *corev1.SecurityContext {
+	if sc == nil {
+		sc = &corev1.SecurityContext{}
+	}
+	if sc.AllowPrivilegeEscalation == nil {
+		sc.AllowPrivilegeEscalation = ptrTo(false)
+	}
+	if sc.ReadOnlyRootFilesystem == nil {
+		sc.ReadOnlyRootFilesystem = ptrTo(true)
+	}
+	if sc.Capabilities == nil {
+		sc.Capabilities = &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}}
+	}
+	return sc
+}
+
+// mergeMaps returns the union of base and overrides, with overrides taking precedence.
+func mergeMaps(base, overrides map[string]string) map[string]string {
+	if len(base) == 0 {
+		return overrides
+	}
+	merged := maps.Clone(base)
+	maps.Copy(merged, overrides)
+	return merged
+}
@@ -0,0 +1,64 @@
+package ctrl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	frontendv1beta1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
+)
+
+func TestBuildDeployment_SecureDefaults(t *testing.T) {
+	page := testFrontend()
+
+	pod := buildDeployment(page, "abc").Spec.Template.Spec
+	require.True(t, *pod.SecurityContext.RunAsNonRoot)
+	require.Equal(t, corev1.SeccompProfileTypeRuntimeDefault, pod.SecurityContext.SeccompProfile.Type)
+
+	container := pod.Containers[0]
+	require.True(t, *container.SecurityContext.ReadOnlyRootFilesystem)
+	require.False(t, *container.SecurityContext.AllowPrivilegeEscalation)
+	require.Equal(t, []corev1.Capability{"ALL"}, container.SecurityContext.Capabilities.Drop)
+	require.Contains(t, container.VolumeMounts, corev1.VolumeMount{Name: tmpVolumeName, MountPath: tmpMountPath},
+		"a read-only root filesystem needs a writable scratch directory")
+}
+
+func TestBuildDeployment_PodTemplate(t *testing.T) {
+	page := testFrontend()
+	page.Spec.PodTemplate = &frontendv1beta1.PodTemplateSpec{
+		Labels:      map[string]string{"team": "web"},
+		Annotations: map[string]string{"example.com/scrape": "true"},
+		Resources: &corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("50m")},
+		},
+		ReadinessProbe: &corev1.Probe{ProbeHandler: corev1.ProbeHandler{
+			HTTPGet: &corev1.HTTPGetAction{Path: "/healthz"},
+		}},
+		Env:              []corev1.EnvVar{{Name: "TZ", Value: "UTC"}},
+		SecurityContext:  &corev1.SecurityContext{ReadOnlyRootFilesystem: ptrTo(false)},
+		ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+		NodeSelector:     map[string]string{"kubernetes.io/os": "linux"},
+		Tolerations:      []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}},
+	}
+
+	tmpl := buildDeployment(page, "abc").Spec.Template
+	require.Equal(t, map[string]string{"app": page.Name, frontendLabel: page.Name, "team": "web"}, tmpl.Labels)
+	require.Equal(t, map[string]string{contentRevisionAnnotation: "abc", "example.com/scrape": "true"}, tmpl.Annotations)
+	require.Equal(t, page.Spec.PodTemplate.ImagePullSecrets, tmpl.Spec.ImagePullSecrets)
+	require.Equal(t, page.Spec.PodTemplate.NodeSelector, tmpl.Spec.NodeSelector)
+	require.Equal(t, page.Spec.PodTemplate.Tolerations, tmpl.Spec.Tolerations)
+
+	container := tmpl.Spec.Containers[0]
+	require.Equal(t, *page.Spec.PodTemplate.Resources, container.Resources)
+	require.Equal(t, "/healthz", container.ReadinessProbe.HTTPGet.Path)
+	require.Equal(t, page.Spec.PodTemplate.Env, container.Env)
+	require.False(t, *container.SecurityContext.ReadOnlyRootFilesystem, "explicit settings override the defaults")
+	require.False(t, *container.SecurityContext.AllowPrivilegeEscalation, "unset fields keep their defaults")
+	require.Nil(t, page.Spec.PodTemplate.SecurityContext.AllowPrivilegeEscalation, "the Frontend spec must not be modified")
+
+	// The selector label cannot be overridden
+	page.Spec.PodTemplate.Labels["app"] = "other"
+	require.Equal(t, page.Name, buildDeployment(page, "abc").Spec.Template.Labels["app"])
+}
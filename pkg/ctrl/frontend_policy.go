@@ -0,0 +1,99 @@
+package ctrl
+
+import (
+	context "context"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	frontendv1beta1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
+)
+
+// defaultMaxUnavailable is the number of pods a disruption budget without limits lets go at once.
+const defaultMaxUnavailable = 1
+
+// podSelector selects the pods of all Deployments of the Frontend. Unlike the app label,
+// which only the pods of the stable Deployment carry, the Frontend label is also set on the
+// pods of the blue/green and canary tracks.
+func podSelector(page *frontendv1beta1.Frontend) map[string]string {
+	return map[string]string{frontendLabel: page.Name}
+}
+
+func buildPodDisruptionBudget(page *frontendv1beta1.Frontend) *policyv1.PodDisruptionBudget {
+	spec := page.Spec.DisruptionBudget
+	pdb := &policyv1.PodDisruptionBudget{
+		TypeMeta: metav1.TypeMeta{APIVersion: "policy/v1", Kind: "PodDisruptionBudget"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      page.Name,
+			Namespace: page.Namespace,
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			Selector:       &metav1.LabelSelector{MatchLabels: podSelector(page)},
+			MinAvailable:   spec.MinAvailable,
+			MaxUnavailable: spec.MaxUnavailable,
+		},
+	}
+	if spec.MinAvailable == nil && spec.MaxUnavailable == nil {
+		maxUnavailable := intstr.FromInt32(defaultMaxUnavailable)
+		pdb.Spec.MaxUnavailable = &maxUnavailable
+	}
+	return pdb
+}
+
+// buildNetworkPolicy returns a NetworkPolicy denying all traffic of the Frontend pods but
+// connections to their HTTP port and the egress allowed by spec.networkPolicy.
+func buildNetworkPolicy(page *frontendv1beta1.Frontend) *networkingv1.NetworkPolicy {
+	spec := page.Spec.NetworkPolicy
+	protocol := corev1.ProtocolTCP
+	port := intstr.FromString(httpPortName)
+	return &networkingv1.NetworkPolicy{
+		TypeMeta: metav1.TypeMeta{APIVersion: "networking.k8s.io/v1", Kind: "NetworkPolicy"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      page.Name,
+			Namespace: page.Namespace,
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: podSelector(page)},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{{
+				Ports: []networkingv1.NetworkPolicyPort{{Protocol: &protocol, Port: &port}},
+				From:  spec.From,
+			}},
+			Egress: spec.Egress,
+		},
+	}
+}
+
+// reconcilePolicies applies the PodDisruptionBudget and NetworkPolicy of the Frontend, or
+// deletes them if they are not requested.
+func (r *FrontendReconciler) reconcilePolicies(ctx context.Context, page *frontendv1beta1.Frontend, specChanged bool) error {
+	objMeta := metav1.ObjectMeta{Name: page.Name, Namespace: page.Namespace}
+
+	if page.Spec.DisruptionBudget == nil {
+		if err := r.deleteOwned(ctx, page, &policyv1.PodDisruptionBudget{ObjectMeta: *objMeta.DeepCopy()}); err != nil {
+			return err
+		}
+	} else {
+		pdb := buildPodDisruptionBudget(page)
+		if err := ctrl.SetControllerReference(page, pdb, r.Scheme); err != nil {
+			return err
+		}
+		if err := r.applyOwned(ctx, page, pdb, specChanged); err != nil {
+			return err
+		}
+	}
+
+	if page.Spec.NetworkPolicy == nil {
+		return r.deleteOwned(ctx, page, &networkingv1.NetworkPolicy{ObjectMeta: objMeta})
+	}
+	np := buildNetworkPolicy(page)
+	if err := ctrl.SetControllerReference(page, np, r.Scheme); err != nil {
+		return err
+	}
+	return r.applyOwned(ctx, page, np, specChanged)
+}
@@ -0,0 +1,64 @@
+package ctrl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	frontendv1beta1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
+)
+
+// requireSelectsAllTracks checks that selector matches the pods of the stable Deployment and
+// of the blue/green and canary tracks.
+func requireSelectsAllTracks(t *testing.T, page *frontendv1beta1.Frontend, selector *metav1.LabelSelector) {
+	t.Helper()
+	sel, err := metav1.LabelSelectorAsSelector(selector)
+	require.NoError(t, err)
+
+	page = page.DeepCopy()
+	require.True(t, sel.Matches(labels.Set(buildDeployment(page, "abc").Spec.Template.Labels)), "stable pods should be selected")
+	for _, strategy := range []frontendv1beta1.RolloutStrategyType{frontendv1beta1.RolloutBlueGreen, frontendv1beta1.RolloutCanary} {
+		page.Spec.RolloutStrategy = &frontendv1beta1.RolloutStrategy{Type: strategy}
+		dep := buildCanaryDeployment(page, "def", 1)
+		require.True(t, sel.Matches(labels.Set(dep.Spec.Template.Labels)), "%s pods should be selected", strategy)
+	}
+}
+
+func TestBuildPodDisruptionBudget(t *testing.T) {
+	page := testFrontend()
+	page.Spec.DisruptionBudget = &frontendv1beta1.DisruptionBudgetSpec{}
+
+	pdb := buildPodDisruptionBudget(page)
+	requireSelectsAllTracks(t, page, pdb.Spec.Selector)
+	require.Equal(t, intstr.FromInt32(1), *pdb.Spec.MaxUnavailable)
+	require.Nil(t, pdb.Spec.MinAvailable)
+
+	page.Spec.DisruptionBudget.MinAvailable = ptrTo(intstr.FromString("50%"))
+	pdb = buildPodDisruptionBudget(page)
+	require.Equal(t, intstr.FromString("50%"), *pdb.Spec.MinAvailable)
+	require.Nil(t, pdb.Spec.MaxUnavailable, "the default applies only without limits")
+}
+
+func TestBuildNetworkPolicy(t *testing.T) {
+	page := testFrontend()
+	page.Spec.NetworkPolicy = &frontendv1beta1.NetworkPolicySpec{}
+
+	np := buildNetworkPolicy(page)
+	requireSelectsAllTracks(t, page, &np.Spec.PodSelector)
+	require.ElementsMatch(t, []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress}, np.Spec.PolicyTypes)
+	require.Len(t, np.Spec.Ingress, 1)
+	require.Equal(t, intstr.FromString(httpPortName), *np.Spec.Ingress[0].Ports[0].Port)
+	require.Empty(t, np.Spec.Ingress[0].From, "any source may connect to the HTTP port")
+	require.Empty(t, np.Spec.Egress, "outgoing traffic should be denied")
+
+	ingressNS := networkingv1.NetworkPolicyPeer{NamespaceSelector: &metav1.LabelSelector{
+		MatchLabels: map[string]string{"kubernetes.io/metadata.name": "ingress-nginx"},
+	}}
+	page.Spec.NetworkPolicy.From = []networkingv1.NetworkPolicyPeer{ingressNS}
+	np = buildNetworkPolicy(page)
+	require.Equal(t, []networkingv1.NetworkPolicyPeer{ingressNS}, np.Spec.Ingress[0].From)
+}
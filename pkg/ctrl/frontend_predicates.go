@@ -0,0 +1,59 @@
+package ctrl
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// frontendChanged passes changes to the spec of a Frontend, which bump its generation, and to
+// its annotations, which pause it or act on a rollout. Status updates made by the controller
+// itself are filtered out.
+var frontendChanged = predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{})
+
+// deploymentChanged passes changes to the spec of a Deployment and to the parts of its status
+// that are reported in the Frontend status.
+var deploymentChanged = predicate.Or(predicate.GenerationChangedPredicate{}, predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldDep, ok := e.ObjectOld.(*appsv1.Deployment)
+		if !ok {
+			return true
+		}
+		newDep, ok := e.ObjectNew.(*appsv1.Deployment)
+		if !ok {
+			return true
+		}
+		return deploymentStatusSummary(oldDep) != deploymentStatusSummary(newDep) ||
+			!equality.Semantic.DeepEqual(deploymentConditions(oldDep), deploymentConditions(newDep))
+	},
+})
+
+// deploymentStatus holds the status fields of a Deployment that computeStatus and the rollouts
+// depend on.
+type deploymentStatus struct {
+	observedGeneration int64
+	replicas           int32
+	updatedReplicas    int32
+	readyReplicas      int32
+}
+
+func deploymentStatusSummary(dep *appsv1.Deployment) deploymentStatus {
+	return deploymentStatus{
+		observedGeneration: dep.Status.ObservedGeneration,
+		replicas:           dep.Status.Replicas,
+		updatedReplicas:    dep.Status.UpdatedReplicas,
+		readyReplicas:      dep.Status.ReadyReplicas,
+	}
+}
+
+// deploymentConditions returns the conditions of dep without their timestamps, which change
+// on every status update.
+func deploymentConditions(dep *appsv1.Deployment) []appsv1.DeploymentCondition {
+	conditions := make([]appsv1.DeploymentCondition, 0, len(dep.Status.Conditions))
+	for _, c := range dep.Status.Conditions {
+		conditions = append(conditions, appsv1.DeploymentCondition{Type: c.Type, Status: c.Status, Reason: c.Reason})
+	}
+	return conditions
+}
@@ -0,0 +1,40 @@
+package ctrl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestDeploymentChanged(t *testing.T) {
+	old := buildDeployment(testFrontend(), "abc")
+	old.Generation = 2
+	old.Status = appsv1.DeploymentStatus{
+		ObservedGeneration: 2,
+		Replicas:           2,
+		ReadyReplicas:      1,
+		Conditions: []appsv1.DeploymentCondition{{
+			Type:           appsv1.DeploymentProgressing,
+			Status:         corev1.ConditionTrue,
+			Reason:         "ReplicaSetUpdated",
+			LastUpdateTime: metav1.Now(),
+		}},
+	}
+	updated := func(change func(dep *appsv1.Deployment)) bool {
+		dep := old.DeepCopy()
+		change(dep)
+		return deploymentChanged.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: dep})
+	}
+
+	require.False(t, updated(func(dep *appsv1.Deployment) {
+		dep.ResourceVersion = "2"
+		dep.Status.Conditions[0].LastUpdateTime = metav1.NewTime(dep.Status.Conditions[0].LastUpdateTime.Add(1))
+	}), "status updates that are not reported should be filtered out")
+	require.True(t, updated(func(dep *appsv1.Deployment) { dep.Status.ReadyReplicas = 2 }))
+	require.True(t, updated(func(dep *appsv1.Deployment) { dep.Status.Conditions[0].Reason = "ProgressDeadlineExceeded" }))
+	require.True(t, updated(func(dep *appsv1.Deployment) { dep.Generation = 3 }), "spec changes may be drift")
+}
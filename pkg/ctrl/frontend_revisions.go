@@ -0,0 +1,168 @@
+package ctrl
+
+import (
+	context "context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	frontendv1beta1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
+)
+
+const (
+	// revisionLabel holds the content revision stored in an immutable revision ConfigMap.
+	revisionLabel = "frontend.oleksandr-san.io/revision"
+	// itemsAnnotation records the paths the keys of a revision ConfigMap are mounted at,
+	// so that a revision can be served again after the files of the spec have changed.
+	itemsAnnotation = "frontend.oleksandr-san.io/items"
+
+	defaultRevisionHistoryLimit = 10
+)
+
+// revisionPattern matches the content revisions returned by contentRevision.
+var revisionPattern = regexp.MustCompile(`^[0-9a-f]{16}$`)
+
+// revisionName returns the name of the immutable ConfigMap storing a content revision.
+func revisionName(page *frontendv1beta1.Frontend, revision string) string {
+	return page.Name + "-" + revision
+}
+
+// contentConfigMapName returns the name of the ConfigMap mounted into the pods. Content
+// updated in place is served from a mutable copy of the revision named after the Frontend.
+func contentConfigMapName(page *frontendv1beta1.Frontend, revision string) string {
+	if page.Spec.ContentUpdatePolicy == frontendv1beta1.ContentUpdateInPlace {
+		return page.Name
+	}
+	return revisionName(page, revision)
+}
+
+// currentConfigMap returns the mutable copy of the revision ConfigMap cm that content
+// updated in place is served from.
+func currentConfigMap(page *frontendv1beta1.Frontend, cm *corev1.ConfigMap) *corev1.ConfigMap {
+	current := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        page.Name,
+			Namespace:   page.Namespace,
+			Annotations: cm.Annotations,
+		},
+		Data:       cm.Data,
+		BinaryData: cm.BinaryData,
+	}
+	return current.DeepCopy()
+}
+
+// revisionItems returns the volume items recorded on a revision ConfigMap.
+func revisionItems(cm *corev1.ConfigMap) []corev1.KeyToPath {
+	var items []corev1.KeyToPath
+	if raw, ok := cm.Annotations[itemsAnnotation]; ok {
+		if err := json.Unmarshal([]byte(raw), &items); err != nil {
+			log.Error().Err(err).Msgf("Ignoring invalid %s annotation of ConfigMap: %s %s", itemsAnnotation, cm.Name, cm.Namespace)
+			return nil
+		}
+	}
+	return items
+}
+
+// setContentVolume mounts the content ConfigMap cm into the pods of dep.
+func setContentVolume(dep *appsv1.Deployment, cm *corev1.ConfigMap) {
+	for i := range dep.Spec.Template.Spec.Volumes {
+		if vol := &dep.Spec.Template.Spec.Volumes[i]; vol.Name == contentsVolumeName {
+			vol.ConfigMap.Name = cm.Name
+			vol.ConfigMap.Items = revisionItems(cm)
+		}
+	}
+}
+
+// contentConfigMapOf returns the name of the content ConfigMap mounted by dep, if any.
+func contentConfigMapOf(dep *appsv1.Deployment) string {
+	if dep == nil {
+		return ""
+	}
+	for _, vol := range dep.Spec.Template.Spec.Volumes {
+		if vol.Name == contentsVolumeName && vol.ConfigMap != nil {
+			return vol.ConfigMap.Name
+		}
+	}
+	return ""
+}
+
+// getRevision returns the retained ConfigMap of a content revision of the Frontend.
+func (r *FrontendReconciler) getRevision(ctx context.Context, page *frontendv1beta1.Frontend, revision string) (*corev1.ConfigMap, error) {
+	cm := &corev1.ConfigMap{}
+	err := r.Get(ctx, client.ObjectKey{Name: revisionName(page, revision), Namespace: page.Namespace}, cm)
+	if client.IgnoreNotFound(err) != nil {
+		return nil, err
+	}
+	if err != nil || !metav1.IsControlledBy(cm, page) || cm.Labels[revisionLabel] != revision {
+		return nil, invalidSpecError{fmt.Errorf("content revision %s is not retained", revision)}
+	}
+	return cm, nil
+}
+
+// listRevisions returns the revision ConfigMaps of the Frontend, newest first.
+func (r *FrontendReconciler) listRevisions(ctx context.Context, page *frontendv1beta1.Frontend) ([]corev1.ConfigMap, error) {
+	var list corev1.ConfigMapList
+	if err := r.List(ctx, &list, client.InNamespace(page.Namespace),
+		client.MatchingLabels{frontendLabel: page.Name}, client.HasLabels{revisionLabel}); err != nil {
+		return nil, err
+	}
+	revisions := slices.DeleteFunc(list.Items, func(cm corev1.ConfigMap) bool {
+		return !metav1.IsControlledBy(&cm, page)
+	})
+	slices.SortFunc(revisions, func(a, b corev1.ConfigMap) int {
+		if c := b.CreationTimestamp.Compare(a.CreationTimestamp.Time); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return revisions, nil
+}
+
+// pruneRevisions deletes the revision ConfigMaps exceeding spec.revisionHistoryLimit. The
+// ConfigMaps named in inUse are never deleted and do not count towards the limit.
+func (r *FrontendReconciler) pruneRevisions(ctx context.Context, page *frontendv1beta1.Frontend, inUse sets.Set[string]) error {
+	limit := defaultRevisionHistoryLimit
+	if page.Spec.RevisionHistoryLimit != nil {
+		limit = int(*page.Spec.RevisionHistoryLimit)
+	}
+
+	revisions, err := r.listRevisions(ctx, page)
+	if err != nil {
+		return err
+	}
+	retained := 0
+	for i := range revisions {
+		cm := &revisions[i]
+		if inUse.Has(cm.Name) {
+			continue
+		}
+		if retained < limit {
+			retained++
+			continue
+		}
+		if err := r.deleteOwned(ctx, page, cm); err != nil {
+			return err
+		}
+	}
+
+	// ConfigMaps named after the Frontend hold the content of earlier versions of the
+	// controller, and of Frontends updating their content in place
+	if !inUse.Has(page.Name) {
+		return r.deleteOwned(ctx, page, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: page.Name, Namespace: page.Namespace},
+		})
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package ctrl
+
+import (
+	context "context"
+	goerrors "errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	frontendv1beta1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
+	testutil "github.com/oleksandr-san/k8s-controller/pkg/testutil"
+)
+
+func TestBuildConfigMap_Revision(t *testing.T) {
+	page := testFrontend()
+	page.Spec.Files["site.css"] = frontendv1beta1.FrontendFile{Path: "css/site.css", Content: "h1 { color: red }"}
+
+	cm := buildConfigMap(page, nil)
+	revision := contentRevision(cm)
+	require.Equal(t, revisionName(page, revision), cm.Name)
+	require.True(t, *cm.Immutable)
+	require.Equal(t, map[string]string{frontendLabel: page.Name, revisionLabel: revision}, cm.Labels)
+
+	dep := buildDeployment(page, revision)
+	want := dep.Spec.Template.Spec.Volumes[0].ConfigMap.DeepCopy()
+	setContentVolume(dep, cm)
+	require.Equal(t, want, dep.Spec.Template.Spec.Volumes[0].ConfigMap, "the revision should record its paths")
+
+	page.Spec.Files["site.css"] = frontendv1beta1.FrontendFile{Path: "site.css", Content: "h1 { color: red }"}
+	require.NotEqual(t, revision, contentRevision(buildConfigMap(page, nil)), "path changes should change the revision")
+
+	page.Spec.ContentUpdatePolicy = frontendv1beta1.ContentUpdateInPlace
+	require.Equal(t, page.Name, buildDeployment(page, revision).Spec.Template.Spec.Volumes[0].ConfigMap.Name,
+		"content updated in place is served from a mutable ConfigMap")
+}
+
+func TestPruneRevisions(t *testing.T) {
+	page := testFrontend()
+	page.UID = "test-page-uid"
+	page.Spec.RevisionHistoryLimit = int32Ptr(1)
+	r := newFakeReconciler(t, page)
+
+	owned := func(cm *corev1.ConfigMap) client.Object {
+		require.NoError(t, ctrl.SetControllerReference(page, cm, r.Scheme))
+		return cm
+	}
+	start := time.Now()
+	revision := func(rev string, age time.Duration) client.Object {
+		return owned(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Name:              revisionName(page, rev),
+			Namespace:         page.Namespace,
+			Labels:            map[string]string{frontendLabel: page.Name, revisionLabel: rev},
+			CreationTimestamp: metav1.NewTime(start.Add(-age)),
+		}})
+	}
+	ctx := context.Background()
+	for _, obj := range []client.Object{
+		revision("0000000000000001", 4*time.Hour),
+		revision("0000000000000002", 3*time.Hour),
+		revision("0000000000000003", 2*time.Hour),
+		revision("0000000000000004", time.Hour),
+		owned(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: page.Name, Namespace: page.Namespace}}),
+	} {
+		require.NoError(t, r.Create(ctx, obj))
+	}
+
+	inUse := sets.New(revisionName(page, "0000000000000004"), revisionName(page, "0000000000000001"))
+	require.NoError(t, r.pruneRevisions(ctx, page, inUse))
+
+	revisions, err := r.listRevisions(ctx, page)
+	require.NoError(t, err)
+	var names []string
+	for _, cm := range revisions {
+		names = append(names, cm.Name)
+	}
+	require.Equal(t, []string{
+		revisionName(page, "0000000000000004"),
+		revisionName(page, "0000000000000003"),
+		revisionName(page, "0000000000000001"),
+	}, names, "revisions in use and the newest one beyond them should be retained")
+	require.True(t, errors.IsNotFound(r.Get(ctx, client.ObjectKeyFromObject(page), &corev1.ConfigMap{})),
+		"the unused ConfigMap named after the Frontend should be deleted")
+
+	_, err = r.getRevision(ctx, page, "0000000000000002")
+	var specErr invalidSpecError
+	require.True(t, goerrors.As(err, &specErr), "rolling back to a pruned revision is a spec error, got %v", err)
+	_, err = r.getRevision(ctx, page, "0000000000000003")
+	require.NoError(t, err)
+}
+
+func TestFrontendReconciler_Rollback(t *testing.T) {
+	mgr, k8sClient, _, cleanup := testutil.StartTestManager(t)
+	defer cleanup()
+
+	require.NoError(t, AddFrontendController(mgr, DefaultFrontendOptions()))
+
+	ctx := context.Background()
+	page := &frontendv1beta1.Frontend{
+		ObjectMeta: metav1.ObjectMeta{Name: "rollback-page", Namespace: "default"},
+		Spec: frontendv1beta1.FrontendSpec{
+			Files:    map[string]frontendv1beta1.FrontendFile{"contents": {Content: "hello world"}},
+			Image:    "nginx:alpine",
+			Replicas: int32Ptr(1),
+		},
+	}
+	require.NoError(t, k8sClient.Create(ctx, page))
+
+	key := client.ObjectKeyFromObject(page)
+	require.Eventually(t, func() bool {
+		return k8sClient.Get(ctx, key, page) == nil && page.Status.ContentRevision != ""
+	}, 10*time.Second, 100*time.Millisecond, "content revision should be reported")
+	first := page.Status.ContentRevision
+
+	page.Spec.Files["contents"] = frontendv1beta1.FrontendFile{Content: "hello again"}
+	require.NoError(t, k8sClient.Update(ctx, page))
+
+	var dep appsv1.Deployment
+	require.Eventually(t, func() bool {
+		return k8sClient.Get(ctx, key, &dep) == nil && contentConfigMapOf(&dep) != revisionName(page, first)
+	}, 10*time.Second, 100*time.Millisecond, "Deployment should serve the new revision")
+
+	var cm corev1.ConfigMap
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKey{Name: revisionName(page, first), Namespace: page.Namespace}, &cm),
+		"the previous revision should be retained")
+	require.Equal(t, "hello world", cm.Data["contents"])
+
+	// Rolling back serves the retained revision until rollbackTo is removed
+	require.NoError(t, k8sClient.Get(ctx, key, page))
+	page.Spec.RollbackTo = first
+	require.NoError(t, k8sClient.Update(ctx, page))
+
+	require.Eventually(t, func() bool {
+		return k8sClient.Get(ctx, key, &dep) == nil && contentConfigMapOf(&dep) == revisionName(page, first) &&
+			k8sClient.Get(ctx, key, page) == nil && page.Status.ContentRevision == first
+	}, 10*time.Second, 100*time.Millisecond, "Deployment should serve the revision rolled back to")
+}
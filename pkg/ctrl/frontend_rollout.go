@@ -0,0 +1,310 @@
+package ctrl
+
+import (
+	context "context"
+	"fmt"
+	"maps"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	frontendv1beta1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
+)
+
+const (
+	// rolloutActionAnnotation requests a manual action on the rollout of a Frontend.
+	// It is removed once the action has been handled.
+	rolloutActionAnnotation = "frontend.oleksandr-san.io/rollout-action"
+	// rolloutActionPromote advances a canary to its next step or switches a blue/green rollout.
+	rolloutActionPromote = "promote"
+	// rolloutActionAbort removes the new revision and keeps the stable one.
+	rolloutActionAbort = "abort"
+
+	// frontendLabel is set on the pods of all Deployments of a Frontend; together with
+	// trackLabel it selects the pods of the canary Deployment.
+	frontendLabel = "frontend.oleksandr-san.io/frontend"
+	trackLabel    = "frontend.oleksandr-san.io/track"
+	canaryTrack   = "canary"
+	// canarySuffix names the canary Deployment after the Frontend. Frontend names ending in it
+	// are rejected, as their Deployment would be the canary Deployment of another Frontend.
+	canarySuffix = "-canary"
+
+	// contentsVolumeName is the volume the content ConfigMap is mounted from.
+	contentsVolumeName = "contents"
+)
+
+// rolloutPlan is the desired state of the objects taking part in a BlueGreen or Canary rollout.
+type rolloutPlan struct {
+	status frontendv1beta1.RolloutStatus
+	// stableRevision is the content revision of the stable ConfigMap and Deployment.
+	stableRevision string
+	// canaryReplicas of the canary Deployment running the new revision. It is deleted if 0.
+	canaryReplicas int32
+	// stableReplicas overrides the replicas of the stable Deployment while a canary runs.
+	stableReplicas *int32
+	// switched routes the Service to the canary pods.
+	switched bool
+	// actionHandled reports that the rollout action annotation can be removed.
+	actionHandled bool
+	// requeueAfter schedules the next canary step.
+	requeueAfter time.Duration
+}
+
+func rolloutStrategy(page *frontendv1beta1.Frontend) frontendv1beta1.RolloutStrategyType {
+	if page.Spec.RolloutStrategy == nil || page.Spec.RolloutStrategy.Type == "" {
+		return frontendv1beta1.RolloutRollingUpdate
+	}
+	return page.Spec.RolloutStrategy.Type
+}
+
+func canaryName(page *frontendv1beta1.Frontend) string {
+	return page.Name + canarySuffix
+}
+
+func canarySelector(page *frontendv1beta1.Frontend) map[string]string {
+	return map[string]string{frontendLabel: page.Name, trackLabel: canaryTrack}
+}
+
+// serviceSelector returns the pods the Service routes to during the rollout, nil for the pods
+// of the stable Deployment.
+func (p rolloutPlan) serviceSelector(page *frontendv1beta1.Frontend) map[string]string {
+	switch {
+	case p.switched:
+		return canarySelector(page)
+	case p.canaryReplicas > 0 && rolloutStrategy(page) == frontendv1beta1.RolloutCanary:
+		// Split the traffic between the stable and canary pods
+		return map[string]string{frontendLabel: page.Name}
+	}
+	return nil
+}
+
+// canaryReplicas returns the number of canary pods running weight percent of total.
+func canaryReplicas(total, weight int32) int32 {
+	return max((total*weight+99)/100, 1)
+}
+
+// planRollout advances the rollout of the content revision of a Frontend using the
+// BlueGreen or Canary strategy. total is the desired number of replicas, stable and
+// canary are the current Deployments, nil if they do not exist.
+func planRollout(page *frontendv1beta1.Frontend, revision string, total int32, stable, canary *appsv1.Deployment, now time.Time) rolloutPlan {
+	strategy := rolloutStrategy(page)
+	action := page.Annotations[rolloutActionAnnotation]
+
+	var current frontendv1beta1.RolloutStatus
+	if page.Status.Rollout != nil {
+		current = *page.Status.Rollout.DeepCopy()
+	}
+	if current.StableRevision == "" {
+		current.StableRevision = page.Status.ContentRevision
+	}
+	if current.StableRevision == "" {
+		// New Frontends have nothing to roll out from
+		current.StableRevision = revision
+	}
+	plan := rolloutPlan{status: current, stableRevision: current.StableRevision}
+
+	switch {
+	case revision == current.StableRevision:
+		if current.Phase == frontendv1beta1.RolloutPhasePromoting && !deploymentAvailable(stable, revision) {
+			// Keep serving from the canary until the stable Deployment has caught up
+			if canary != nil && canary.Spec.Replicas != nil {
+				plan.canaryReplicas = *canary.Spec.Replicas
+			}
+			plan.switched = strategy == frontendv1beta1.RolloutBlueGreen && plan.canaryReplicas > 0
+			return plan
+		}
+		plan.status = frontendv1beta1.RolloutStatus{Phase: frontendv1beta1.RolloutPhaseStable, StableRevision: revision}
+		plan.actionHandled = true
+		return plan
+	case current.Phase == frontendv1beta1.RolloutPhaseAborted && current.CanaryRevision == revision:
+		plan.actionHandled = true
+		return plan
+	case current.CanaryRevision != revision || current.Phase == frontendv1beta1.RolloutPhaseStable:
+		plan.status = frontendv1beta1.RolloutStatus{
+			Phase:          frontendv1beta1.RolloutPhaseProgressing,
+			StableRevision: current.StableRevision,
+			CanaryRevision: revision,
+		}
+	}
+
+	if action == rolloutActionAbort {
+		plan.status.Phase = frontendv1beta1.RolloutPhaseAborted
+		plan.status.StepStartTime = nil
+		plan.actionHandled = true
+		return plan
+	}
+
+	var steps []int32
+	if strategy == frontendv1beta1.RolloutCanary {
+		steps = page.Spec.RolloutStrategy.Canary.Steps
+	}
+	setStep := func(step int32) {
+		plan.status.Step = step
+		plan.canaryReplicas = total
+		plan.stableReplicas = nil
+		if len(steps) > 0 {
+			plan.canaryReplicas = canaryReplicas(total, steps[min(int(step), len(steps)-1)])
+			stableReplicas := max(total-plan.canaryReplicas, min(total, 1))
+			plan.stableReplicas = &stableReplicas
+		}
+	}
+	setStep(plan.status.Step)
+
+	if canary == nil || canary.Spec.Replicas == nil || *canary.Spec.Replicas != plan.canaryReplicas || !deploymentAvailable(canary, revision) {
+		plan.status.Phase = frontendv1beta1.RolloutPhaseProgressing
+		plan.status.StepStartTime = nil
+		return plan
+	}
+	if plan.status.StepStartTime == nil {
+		startTime := metav1.NewTime(now)
+		plan.status.StepStartTime = &startTime
+	}
+
+	promote := action == rolloutActionPromote
+	if !promote {
+		switch strategy {
+		case frontendv1beta1.RolloutBlueGreen:
+			if page.Spec.RolloutStrategy.BlueGreen != nil && page.Spec.RolloutStrategy.BlueGreen.ManualPromotion {
+				plan.status.Phase = frontendv1beta1.RolloutPhasePaused
+				return plan
+			}
+		case frontendv1beta1.RolloutCanary:
+			stepDuration := page.Spec.RolloutStrategy.Canary.StepDuration
+			if stepDuration == nil {
+				plan.status.Phase = frontendv1beta1.RolloutPhasePaused
+				return plan
+			}
+			if elapsed := now.Sub(plan.status.StepStartTime.Time); elapsed < stepDuration.Duration {
+				plan.status.Phase = frontendv1beta1.RolloutPhaseProgressing
+				plan.requeueAfter = stepDuration.Duration - elapsed
+				return plan
+			}
+		}
+	}
+	plan.actionHandled = true
+
+	if int(plan.status.Step)+1 < len(steps) {
+		plan.status.Phase = frontendv1beta1.RolloutPhaseProgressing
+		plan.status.StepStartTime = nil
+		setStep(plan.status.Step + 1)
+		return plan
+	}
+
+	// The new revision is promoted by rolling it out to the stable Deployment
+	plan.status.Phase = frontendv1beta1.RolloutPhasePromoting
+	plan.status.StableRevision = revision
+	plan.stableRevision = revision
+	plan.stableReplicas = nil
+	plan.switched = strategy == frontendv1beta1.RolloutBlueGreen
+	return plan
+}
+
+// setRolloutStatus records the state of the rollout in the Frontend status. A rollout in
+// progress is reported by the Progressing condition.
+func setRolloutStatus(status *frontendv1beta1.FrontendStatus, rollout *frontendv1beta1.RolloutStatus, generation int64) {
+	status.Rollout = rollout
+	if rollout == nil {
+		return
+	}
+	var message string
+	switch rollout.Phase {
+	case frontendv1beta1.RolloutPhaseProgressing:
+		message = fmt.Sprintf("Rolling out content revision %s", rollout.CanaryRevision)
+	case frontendv1beta1.RolloutPhasePaused:
+		message = fmt.Sprintf("Rollout of content revision %s waits for the %s action", rollout.CanaryRevision, rolloutActionPromote)
+	case frontendv1beta1.RolloutPhasePromoting:
+		message = fmt.Sprintf("Promoting content revision %s", rollout.StableRevision)
+	default:
+		return
+	}
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               frontendv1beta1.ConditionProgressing,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Rollout" + string(rollout.Phase),
+		Message:            message,
+		ObservedGeneration: generation,
+	})
+}
+
+// deploymentAvailable reports whether dep runs the content revision with all of its replicas ready.
+func deploymentAvailable(dep *appsv1.Deployment, revision string) bool {
+	if dep == nil || dep.Spec.Template.Annotations[contentRevisionAnnotation] != revision {
+		return false
+	}
+	replicas := int32(1)
+	if dep.Spec.Replicas != nil {
+		replicas = *dep.Spec.Replicas
+	}
+	return dep.Status.ObservedGeneration >= dep.Generation &&
+		dep.Status.UpdatedReplicas >= replicas &&
+		dep.Status.ReadyReplicas >= replicas &&
+		dep.Status.Replicas == dep.Status.UpdatedReplicas
+}
+
+// buildCanaryDeployment returns the Deployment running the new content revision next to the
+// stable one. Canary pods do not carry the app label, so that they are not selected by the
+// stable Deployment; the Service selects them as set by rolloutPlan.serviceSelector.
+func buildCanaryDeployment(page *frontendv1beta1.Frontend, revision string, replicas int32) *appsv1.Deployment {
+	dep := buildDeployment(page, revision)
+	dep.Name = canaryName(page)
+	dep.Spec.Replicas = &replicas
+	dep.Spec.Selector = &metav1.LabelSelector{MatchLabels: canarySelector(page)}
+
+	labels := maps.Clone(dep.Spec.Template.Labels)
+	delete(labels, "app")
+	maps.Copy(labels, canarySelector(page))
+	dep.Spec.Template.Labels = labels
+	return dep
+}
+
+// frozenDeployment keeps the content of the stable Deployment while a rollout is in progress,
+// so that only changes other than the content reach its pods.
+func frozenDeployment(dep, existing *appsv1.Deployment, stableRevision string) {
+	if existing == nil {
+		return
+	}
+	dep.Spec.Template.Annotations = mergeMaps(dep.Spec.Template.Annotations, map[string]string{contentRevisionAnnotation: stableRevision})
+	for _, vol := range existing.Spec.Template.Spec.Volumes {
+		if vol.Name != contentsVolumeName || vol.ConfigMap == nil {
+			continue
+		}
+		for i := range dep.Spec.Template.Spec.Volumes {
+			if own := &dep.Spec.Template.Spec.Volumes[i]; own.Name == contentsVolumeName {
+				own.ConfigMap = vol.ConfigMap.DeepCopy()
+			}
+		}
+	}
+}
+
+// reconcileCanary applies the Deployment serving the content revision cm, or deletes it if no
+// rollout is in progress.
+func (r *FrontendReconciler) reconcileCanary(ctx context.Context, page *frontendv1beta1.Frontend, cm *corev1.ConfigMap, revision string, plan rolloutPlan, specChanged bool) error {
+	if plan.canaryReplicas == 0 {
+		return r.deleteOwned(ctx, page, &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: canaryName(page), Namespace: page.Namespace},
+		})
+	}
+
+	dep := buildCanaryDeployment(page, revision, plan.canaryReplicas)
+	setContentVolume(dep, cm)
+	if err := ctrl.SetControllerReference(page, dep, r.Scheme); err != nil {
+		return err
+	}
+	return r.applyOwned(ctx, page, dep, specChanged)
+}
+
+// clearRolloutAction removes the handled rollout action annotation from the Frontend.
+func (r *FrontendReconciler) clearRolloutAction(ctx context.Context, page *frontendv1beta1.Frontend) error {
+	if _, ok := page.Annotations[rolloutActionAnnotation]; !ok {
+		return nil
+	}
+	patch := client.MergeFrom(page.DeepCopy())
+	delete(page.Annotations, rolloutActionAnnotation)
+	return r.Patch(ctx, page, patch)
+}
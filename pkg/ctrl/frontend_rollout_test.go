@@ -0,0 +1,264 @@
+package ctrl
+
+import (
+	context "context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	frontendv1beta1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
+	testutil "github.com/oleksandr-san/k8s-controller/pkg/testutil"
+)
+
+// availableDeployment returns a Deployment that has rolled out the content revision to all replicas.
+func availableDeployment(revision string, replicas int32) *appsv1.Deployment {
+	dep := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Generation: 1},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status: appsv1.DeploymentStatus{
+			ObservedGeneration: 1,
+			Replicas:           replicas,
+			UpdatedReplicas:    replicas,
+			ReadyReplicas:      replicas,
+		},
+	}
+	dep.Spec.Template.Annotations = map[string]string{contentRevisionAnnotation: revision}
+	return dep
+}
+
+func rolloutFrontend(strategy frontendv1beta1.RolloutStrategy) *frontendv1beta1.Frontend {
+	page := testFrontend()
+	page.Spec.RolloutStrategy = &strategy
+	page.Status.ContentRevision = "old"
+	return page
+}
+
+func TestPlanRollout_BlueGreen(t *testing.T) {
+	page := rolloutFrontend(frontendv1beta1.RolloutStrategy{Type: frontendv1beta1.RolloutBlueGreen})
+	stable := availableDeployment("old", 2)
+	now := time.Now()
+
+	// Unchanged content needs no rollout
+	plan := planRollout(page, "old", 2, stable, nil, now)
+	require.Equal(t, frontendv1beta1.RolloutPhaseStable, plan.status.Phase)
+	require.Zero(t, plan.canaryReplicas)
+
+	// The new revision starts next to the stable one without receiving traffic
+	plan = planRollout(page, "new", 2, stable, nil, now)
+	require.Equal(t, frontendv1beta1.RolloutPhaseProgressing, plan.status.Phase)
+	require.Equal(t, "old", plan.stableRevision)
+	require.Equal(t, "new", plan.status.CanaryRevision)
+	require.Equal(t, int32(2), plan.canaryReplicas)
+	require.Nil(t, plan.stableReplicas)
+	require.False(t, plan.switched)
+
+	// Traffic is switched once the new revision is ready
+	page.Status.Rollout = &plan.status
+	plan = planRollout(page, "new", 2, stable, availableDeployment("new", 2), now)
+	require.Equal(t, frontendv1beta1.RolloutPhasePromoting, plan.status.Phase)
+	require.Equal(t, "new", plan.stableRevision)
+	require.True(t, plan.switched)
+
+	// The canary keeps serving until the stable Deployment has caught up
+	page.Status.Rollout = &plan.status
+	plan = planRollout(page, "new", 2, stable, availableDeployment("new", 2), now)
+	require.Equal(t, frontendv1beta1.RolloutPhasePromoting, plan.status.Phase)
+	require.Equal(t, int32(2), plan.canaryReplicas)
+	require.True(t, plan.switched)
+
+	plan = planRollout(page, "new", 2, availableDeployment("new", 2), availableDeployment("new", 2), now)
+	require.Equal(t, frontendv1beta1.RolloutPhaseStable, plan.status.Phase)
+	require.Equal(t, "new", plan.status.StableRevision)
+	require.Zero(t, plan.canaryReplicas)
+	require.False(t, plan.switched)
+}
+
+func TestPlanRollout_BlueGreenManualPromotion(t *testing.T) {
+	page := rolloutFrontend(frontendv1beta1.RolloutStrategy{
+		Type:      frontendv1beta1.RolloutBlueGreen,
+		BlueGreen: &frontendv1beta1.BlueGreenStrategy{ManualPromotion: true},
+	})
+	stable := availableDeployment("old", 2)
+	canary := availableDeployment("new", 2)
+
+	plan := planRollout(page, "new", 2, stable, canary, time.Now())
+	require.Equal(t, frontendv1beta1.RolloutPhasePaused, plan.status.Phase)
+	require.False(t, plan.switched)
+	require.False(t, plan.actionHandled)
+
+	page.Status.Rollout = &plan.status
+	page.Annotations = map[string]string{rolloutActionAnnotation: rolloutActionPromote}
+	plan = planRollout(page, "new", 2, stable, canary, time.Now())
+	require.Equal(t, frontendv1beta1.RolloutPhasePromoting, plan.status.Phase)
+	require.True(t, plan.switched)
+	require.True(t, plan.actionHandled)
+}
+
+func TestPlanRollout_Canary(t *testing.T) {
+	page := rolloutFrontend(frontendv1beta1.RolloutStrategy{
+		Type:   frontendv1beta1.RolloutCanary,
+		Canary: &frontendv1beta1.CanaryStrategy{Steps: []int32{25, 50}, StepDuration: &metav1.Duration{Duration: time.Minute}},
+	})
+	stable := availableDeployment("old", 3)
+	now := time.Now()
+
+	plan := planRollout(page, "new", 4, stable, nil, now)
+	require.Equal(t, frontendv1beta1.RolloutPhaseProgressing, plan.status.Phase)
+	require.Equal(t, int32(1), plan.canaryReplicas)
+	require.Equal(t, int32(3), *plan.stableReplicas)
+	require.Nil(t, plan.status.StepStartTime)
+
+	// The step runs for its duration once the canary is ready
+	page.Status.Rollout = &plan.status
+	plan = planRollout(page, "new", 4, stable, availableDeployment("new", 1), now)
+	require.Equal(t, frontendv1beta1.RolloutPhaseProgressing, plan.status.Phase)
+	require.Equal(t, int32(0), plan.status.Step)
+	require.Equal(t, time.Minute, plan.requeueAfter)
+
+	page.Status.Rollout = &plan.status
+	plan = planRollout(page, "new", 4, stable, availableDeployment("new", 1), now.Add(time.Minute))
+	require.Equal(t, int32(1), plan.status.Step)
+	require.Equal(t, int32(2), plan.canaryReplicas)
+	require.Equal(t, int32(2), *plan.stableReplicas)
+	require.Nil(t, plan.status.StepStartTime)
+
+	// The new revision is promoted after the last step
+	page.Status.Rollout = &plan.status
+	plan = planRollout(page, "new", 4, stable, availableDeployment("new", 2), now.Add(2*time.Minute))
+	page.Status.Rollout = &plan.status
+	plan = planRollout(page, "new", 4, stable, availableDeployment("new", 2), now.Add(3*time.Minute))
+	require.Equal(t, frontendv1beta1.RolloutPhasePromoting, plan.status.Phase)
+	require.Equal(t, "new", plan.stableRevision)
+	require.Nil(t, plan.stableReplicas)
+	require.False(t, plan.switched, "the Service selects both tracks during a canary rollout")
+}
+
+func TestPlanRollout_CanaryManualSteps(t *testing.T) {
+	page := rolloutFrontend(frontendv1beta1.RolloutStrategy{
+		Type:   frontendv1beta1.RolloutCanary,
+		Canary: &frontendv1beta1.CanaryStrategy{Steps: []int32{10, 50}},
+	})
+	stable := availableDeployment("old", 9)
+
+	plan := planRollout(page, "new", 10, stable, availableDeployment("new", 1), time.Now())
+	require.Equal(t, frontendv1beta1.RolloutPhasePaused, plan.status.Phase)
+
+	page.Status.Rollout = &plan.status
+	page.Annotations = map[string]string{rolloutActionAnnotation: rolloutActionPromote}
+	plan = planRollout(page, "new", 10, stable, availableDeployment("new", 1), time.Now())
+	require.Equal(t, frontendv1beta1.RolloutPhaseProgressing, plan.status.Phase)
+	require.Equal(t, int32(1), plan.status.Step)
+	require.Equal(t, int32(5), plan.canaryReplicas)
+	require.True(t, plan.actionHandled)
+}
+
+func TestPlanRollout_Abort(t *testing.T) {
+	page := rolloutFrontend(frontendv1beta1.RolloutStrategy{Type: frontendv1beta1.RolloutBlueGreen})
+	stable := availableDeployment("old", 2)
+
+	plan := planRollout(page, "new", 2, stable, nil, time.Now())
+	page.Status.Rollout = &plan.status
+	page.Annotations = map[string]string{rolloutActionAnnotation: rolloutActionAbort}
+	plan = planRollout(page, "new", 2, stable, availableDeployment("new", 2), time.Now())
+	require.Equal(t, frontendv1beta1.RolloutPhaseAborted, plan.status.Phase)
+	require.Equal(t, "old", plan.stableRevision)
+	require.Zero(t, plan.canaryReplicas)
+	require.True(t, plan.actionHandled)
+
+	// The aborted revision is not rolled out again
+	page.Status.Rollout = &plan.status
+	page.Annotations = nil
+	plan = planRollout(page, "new", 2, stable, nil, time.Now())
+	require.Equal(t, frontendv1beta1.RolloutPhaseAborted, plan.status.Phase)
+	require.Zero(t, plan.canaryReplicas)
+
+	// but the next content change is
+	plan = planRollout(page, "newer", 2, stable, nil, time.Now())
+	require.Equal(t, frontendv1beta1.RolloutPhaseProgressing, plan.status.Phase)
+	require.Equal(t, "newer", plan.status.CanaryRevision)
+}
+
+func TestBuildCanaryDeployment(t *testing.T) {
+	page := rolloutFrontend(frontendv1beta1.RolloutStrategy{Type: frontendv1beta1.RolloutCanary})
+
+	dep := buildCanaryDeployment(page, "new", 1)
+	require.Equal(t, page.Name+"-canary", dep.Name)
+	require.Equal(t, canarySelector(page), dep.Spec.Selector.MatchLabels)
+	require.NotContains(t, dep.Spec.Template.Labels, "app", "canary pods should not match the selector of the stable Deployment")
+	require.Equal(t, revisionName(page, "new"), dep.Spec.Template.Spec.Volumes[0].ConfigMap.Name)
+	require.Equal(t, "new", dep.Spec.Template.Annotations[contentRevisionAnnotation])
+
+	stable, err := metav1.LabelSelectorAsSelector(buildDeployment(page, "old").Spec.Selector)
+	require.NoError(t, err)
+	require.False(t, stable.Matches(labels.Set(dep.Spec.Template.Labels)), "the Deployment selectors should not overlap")
+
+	// The Service splits the traffic between both Deployments during a canary rollout
+	selector := rolloutPlan{canaryReplicas: 1}.serviceSelector(page)
+	require.True(t, labels.SelectorFromSet(selector).Matches(labels.Set(dep.Spec.Template.Labels)))
+	require.True(t, labels.SelectorFromSet(selector).Matches(labels.Set(buildDeployment(page, "old").Spec.Template.Labels)))
+	require.Nil(t, rolloutPlan{}.serviceSelector(page))
+
+	// but only switches to the blue/green pods
+	page.Spec.RolloutStrategy.Type = frontendv1beta1.RolloutBlueGreen
+	require.Nil(t, rolloutPlan{canaryReplicas: 1}.serviceSelector(page))
+	require.Equal(t, canarySelector(page), rolloutPlan{canaryReplicas: 1, switched: true}.serviceSelector(page))
+}
+
+func TestFrontendReconciler_BlueGreen(t *testing.T) {
+	mgr, k8sClient, _, cleanup := testutil.StartTestManager(t)
+	defer cleanup()
+
+	require.NoError(t, AddFrontendController(mgr, DefaultFrontendOptions()))
+
+	ctx := context.Background()
+	page := &frontendv1beta1.Frontend{
+		ObjectMeta: metav1.ObjectMeta{Name: "blue-green-page", Namespace: "default"},
+		Spec: frontendv1beta1.FrontendSpec{
+			Files:           map[string]frontendv1beta1.FrontendFile{"contents": {Content: "hello world"}},
+			Image:           "nginx:alpine",
+			Replicas:        int32Ptr(2),
+			RolloutStrategy: &frontendv1beta1.RolloutStrategy{Type: frontendv1beta1.RolloutBlueGreen},
+		},
+	}
+	require.NoError(t, k8sClient.Create(ctx, page))
+
+	key := client.ObjectKeyFromObject(page)
+	require.Eventually(t, func() bool {
+		return k8sClient.Get(ctx, key, page) == nil && page.Status.Rollout != nil &&
+			page.Status.Rollout.Phase == frontendv1beta1.RolloutPhaseStable
+	}, 10*time.Second, 100*time.Millisecond, "Frontend should be stable")
+	stableRevision := page.Status.ContentRevision
+
+	// A content change starts the new revision next to the stable one
+	page.Spec.Files["contents"] = frontendv1beta1.FrontendFile{Content: "hello again"}
+	require.NoError(t, k8sClient.Update(ctx, page))
+
+	canaryKey := client.ObjectKey{Name: canaryName(page), Namespace: page.Namespace}
+	var canary appsv1.Deployment
+	require.Eventually(t, func() bool {
+		return k8sClient.Get(ctx, canaryKey, &canary) == nil
+	}, 10*time.Second, 100*time.Millisecond, "canary Deployment should be created")
+
+	var stable appsv1.Deployment
+	require.NoError(t, k8sClient.Get(ctx, key, &stable))
+	require.Equal(t, stableRevision, stable.Spec.Template.Annotations[contentRevisionAnnotation], "stable content should be kept")
+	var svc corev1.Service
+	require.NoError(t, k8sClient.Get(ctx, key, &svc))
+	require.Equal(t, map[string]string{"app": page.Name}, svc.Spec.Selector)
+
+	// Once the new revision is ready, the Service is switched to it
+	canary.Status = appsv1.DeploymentStatus{ObservedGeneration: canary.Generation, Replicas: 2, UpdatedReplicas: 2, ReadyReplicas: 2}
+	require.NoError(t, k8sClient.Status().Update(ctx, &canary))
+
+	require.Eventually(t, func() bool {
+		return k8sClient.Get(ctx, key, &svc) == nil && svc.Spec.Selector[trackLabel] == canaryTrack
+	}, 10*time.Second, 100*time.Millisecond, "Service should be switched to the new revision")
+	require.NoError(t, k8sClient.Get(ctx, key, page))
+	require.Equal(t, frontendv1beta1.RolloutPhasePromoting, page.Status.Rollout.Phase)
+}
@@ -0,0 +1,162 @@
+package ctrl
+
+import (
+	context "context"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	frontendv1beta1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
+)
+
+const (
+	// httpPortName is the name of the container port the Service targets.
+	httpPortName = "http"
+	// unprivilegedPort is the port the default image serves on, as non-root images cannot
+	// bind to privileged ports.
+	unprivilegedPort = 8080
+	// defaultContainerPort is the port other images are expected to serve on.
+	defaultContainerPort = 80
+
+	defaultServicePort = 80
+	defaultIngressPath = "/"
+)
+
+// containerPort returns the port the Frontend container serves on.
+func containerPort(page *frontendv1beta1.Frontend) int32 {
+	if page.Spec.ContainerPort != 0 {
+		return page.Spec.ContainerPort
+	}
+	// Other tags of the default image, or digests pinned by image updates, run as non-root too
+	if image := page.Spec.Image; image == "" || parseImageRef(image).name == parseImageRef(defaultImage).name {
+		return unprivilegedPort
+	}
+	return defaultContainerPort
+}
+
+func buildService(page *frontendv1beta1.Frontend) *corev1.Service {
+	var spec frontendv1beta1.ServiceSpec
+	if page.Spec.Service != nil {
+		spec = *page.Spec.Service
+	}
+	if spec.Type == "" {
+		spec.Type = corev1.ServiceTypeClusterIP
+	}
+	if spec.Port == 0 {
+		spec.Port = defaultServicePort
+	}
+
+	return &corev1.Service{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        page.Name,
+			Namespace:   page.Namespace,
+			Annotations: spec.Annotations,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     spec.Type,
+			Selector: map[string]string{"app": page.Name},
+			Ports: []corev1.ServicePort{{
+				Name:       httpPortName,
+				Port:       spec.Port,
+				Protocol:   corev1.ProtocolTCP,
+				TargetPort: intstr.FromString(httpPortName),
+			}},
+		},
+	}
+}
+
+func buildIngress(page *frontendv1beta1.Frontend, svc *corev1.Service) *networkingv1.Ingress {
+	spec := page.Spec.Ingress
+	path := spec.Path
+	if path == "" {
+		path = defaultIngressPath
+	}
+	pathType := networkingv1.PathTypePrefix
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{APIVersion: "networking.k8s.io/v1", Kind: "Ingress"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      page.Name,
+			Namespace: page.Namespace,
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: spec.IngressClassName,
+			Rules: []networkingv1.IngressRule{{
+				Host: spec.Host,
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{
+							Path:     path,
+							PathType: &pathType,
+							Backend: networkingv1.IngressBackend{
+								Service: &networkingv1.IngressServiceBackend{
+									Name: svc.Name,
+									Port: networkingv1.ServiceBackendPort{Name: httpPortName},
+								},
+							},
+						}},
+					},
+				},
+			}},
+		},
+	}
+	if spec.TLSSecretName != "" {
+		ing.Spec.TLS = []networkingv1.IngressTLS{{
+			Hosts:      []string{spec.Host},
+			SecretName: spec.TLSSecretName,
+		}}
+	}
+	return ing
+}
+
+// reconcileNetworking applies the Service of the Frontend and its Ingress, if requested.
+// A non-nil selector routes the Service to other pods than the ones of the stable Deployment.
+func (r *FrontendReconciler) reconcileNetworking(ctx context.Context, page *frontendv1beta1.Frontend, selector map[string]string, specChanged bool) error {
+	svc := buildService(page)
+	if selector != nil {
+		svc.Spec.Selector = selector
+	}
+	if err := ctrl.SetControllerReference(page, svc, r.Scheme); err != nil {
+		return err
+	}
+
+	if err := r.applyOwned(ctx, page, svc, specChanged); err != nil {
+		return err
+	}
+
+	if page.Spec.Ingress == nil {
+		return r.deleteOwned(ctx, page, &networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: page.Name, Namespace: page.Namespace},
+		})
+	}
+
+	ing := buildIngress(page, svc)
+	if err := ctrl.SetControllerReference(page, ing, r.Scheme); err != nil {
+		return err
+	}
+
+	return r.applyOwned(ctx, page, ing, specChanged)
+}
+
+// deleteOwned deletes obj if it exists and is controlled by the Frontend.
+func (r *FrontendReconciler) deleteOwned(ctx context.Context, page *frontendv1beta1.Frontend, obj client.Object) error {
+	if err := r.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !metav1.IsControlledBy(obj, page) {
+		return nil
+	}
+
+	if err := r.Delete(ctx, obj); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	summaryFrom(ctx).changed("Deleted", r.kindOf(obj), obj.GetName())
+	r.Recorder.Eventf(page, corev1.EventTypeNormal, eventReasonDeleted, "Deleted %s %s", r.kindOf(obj), obj.GetName())
+	return nil
+}
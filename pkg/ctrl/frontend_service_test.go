@@ -0,0 +1,129 @@
+package ctrl
+
+import (
+	context "context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	frontendv1beta1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
+	testutil "github.com/oleksandr-san/k8s-controller/pkg/testutil"
+)
+
+func TestBuildService_Defaults(t *testing.T) {
+	page := testFrontend()
+
+	svc := buildService(page)
+	require.Equal(t, corev1.ServiceTypeClusterIP, svc.Spec.Type)
+	require.Equal(t, map[string]string{"app": page.Name}, svc.Spec.Selector)
+	require.Len(t, svc.Spec.Ports, 1)
+	require.Equal(t, int32(80), svc.Spec.Ports[0].Port)
+	require.Equal(t, httpPortName, svc.Spec.Ports[0].TargetPort.StrVal)
+
+	page.Spec.Service = &frontendv1beta1.ServiceSpec{
+		Type:        corev1.ServiceTypeLoadBalancer,
+		Port:        8080,
+		Annotations: map[string]string{"example.com/lb": "internal"},
+	}
+	svc = buildService(page)
+	require.Equal(t, corev1.ServiceTypeLoadBalancer, svc.Spec.Type)
+	require.Equal(t, int32(8080), svc.Spec.Ports[0].Port)
+	require.Equal(t, "internal", svc.Annotations["example.com/lb"])
+}
+
+func TestContainerPort(t *testing.T) {
+	page := testFrontend()
+	require.Equal(t, int32(80), containerPort(page), "custom images should keep serving on port 80")
+
+	for _, image := range []string{"", defaultImage, "nginxinc/nginx-unprivileged:1.27@sha256:aaaa"} {
+		page.Spec.Image = image
+		require.Equal(t, int32(8080), containerPort(page), image)
+	}
+
+	page.Spec.ContainerPort = 3000
+	require.Equal(t, int32(3000), containerPort(page))
+	dep := buildDeployment(page, "rev")
+	require.Equal(t, int32(3000), dep.Spec.Template.Spec.Containers[0].Ports[0].ContainerPort)
+}
+
+func TestApplyOwned_OwnershipConflict(t *testing.T) {
+	page := testFrontend()
+	handWritten := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: page.Name, Namespace: page.Namespace},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "web", Port: 8000}}},
+	}
+	r := newFakeReconciler(t, page, handWritten)
+
+	ctx := context.Background()
+	err := r.applyOwned(ctx, page, buildService(page), false)
+	var conflictErr ownershipConflictError
+	require.ErrorAs(t, err, &conflictErr)
+	require.ErrorContains(t, err, "Service test-page already exists and is not controlled by the Frontend")
+
+	var got corev1.Service
+	require.NoError(t, r.Get(ctx, client.ObjectKeyFromObject(handWritten), &got))
+	require.Empty(t, got.OwnerReferences, "the Service should not be taken over")
+	require.Equal(t, handWritten.Spec.Ports, got.Spec.Ports)
+}
+
+func TestBuildIngress(t *testing.T) {
+	page := testFrontend()
+	className := "nginx"
+	page.Spec.Ingress = &frontendv1beta1.IngressSpec{
+		Host:             "www.example.com",
+		IngressClassName: &className,
+		TLSSecretName:    "www-tls",
+	}
+
+	ing := buildIngress(page, buildService(page))
+	require.Equal(t, &className, ing.Spec.IngressClassName)
+	require.Len(t, ing.Spec.Rules, 1)
+	require.Equal(t, "www.example.com", ing.Spec.Rules[0].Host)
+	path := ing.Spec.Rules[0].HTTP.Paths[0]
+	require.Equal(t, "/", path.Path)
+	require.Equal(t, page.Name, path.Backend.Service.Name)
+	require.Equal(t, []networkingv1.IngressTLS{{Hosts: []string{"www.example.com"}, SecretName: "www-tls"}}, ing.Spec.TLS)
+}
+
+func TestFrontendReconciler_ServiceAndIngress(t *testing.T) {
+	mgr, k8sClient, _, cleanup := testutil.StartTestManager(t)
+	defer cleanup()
+
+	require.NoError(t, AddFrontendController(mgr, DefaultFrontendOptions()))
+
+	ctx := context.Background()
+	page := &frontendv1beta1.Frontend{
+		ObjectMeta: metav1.ObjectMeta{Name: "exposed-page", Namespace: "default"},
+		Spec: frontendv1beta1.FrontendSpec{
+			Files:    map[string]frontendv1beta1.FrontendFile{"contents": {Content: "hello world"}},
+			Image:    "nginx:alpine",
+			Replicas: int32Ptr(1),
+			Ingress:  &frontendv1beta1.IngressSpec{Host: "www.example.com"},
+		},
+	}
+	require.NoError(t, k8sClient.Create(ctx, page))
+
+	key := client.ObjectKeyFromObject(page)
+	var svc corev1.Service
+	var ing networkingv1.Ingress
+	require.Eventually(t, func() bool {
+		return k8sClient.Get(ctx, key, &svc) == nil && k8sClient.Get(ctx, key, &ing) == nil
+	}, 10*time.Second, 100*time.Millisecond, "Service and Ingress should be created")
+	require.True(t, metav1.IsControlledBy(&svc, page))
+	require.True(t, metav1.IsControlledBy(&ing, page))
+
+	// Dropping the ingress block removes the Ingress
+	require.NoError(t, k8sClient.Get(ctx, key, page))
+	page.Spec.Ingress = nil
+	require.NoError(t, k8sClient.Update(ctx, page))
+
+	require.Eventually(t, func() bool {
+		return errors.IsNotFound(k8sClient.Get(ctx, key, &ing))
+	}, 10*time.Second, 100*time.Millisecond, "Ingress should be deleted")
+}
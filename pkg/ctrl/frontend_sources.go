@@ -0,0 +1,214 @@
+package ctrl
+
+import (
+	context "context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	frontendv1beta1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
+)
+
+// Field indexes of Frontends by the names of the objects referenced in spec.contentsFrom
+const (
+	configMapSourceIndex = ".spec.contentsFrom.configMapKeyRef.name"
+	secretSourceIndex    = ".spec.contentsFrom.secretKeyRef.name"
+	frontendSourceIndex  = ".spec.contentsFrom.frontendRef.name"
+)
+
+// missingSourceError is returned when a referenced content source does not exist yet.
+// The Frontend is reconciled again once the source is created.
+type missingSourceError struct {
+	error
+}
+
+func (e missingSourceError) Unwrap() error { return e.error }
+
+// sourceKey returns the key the content of src is stored under.
+func sourceKey(src frontendv1beta1.ContentSource) string {
+	switch {
+	case src.ConfigMapKeyRef != nil:
+		return src.ConfigMapKeyRef.Key
+	case src.SecretKeyRef != nil:
+		return src.SecretKeyRef.Key
+	case src.FrontendRef != nil:
+		return src.FrontendRef.Key
+	}
+	return ""
+}
+
+// validateContentSources checks every content source references exactly one object.
+// Keys and paths are checked together with the inline files by validateContent.
+func validateContentSources(page *frontendv1beta1.Frontend) field.ErrorList {
+	var errs field.ErrorList
+	for i, src := range page.Spec.ContentsFrom {
+		srcPath := field.NewPath("spec", "contentsFrom").Index(i)
+
+		refs := 0
+		if src.ConfigMapKeyRef != nil {
+			refs++
+		}
+		if src.SecretKeyRef != nil {
+			refs++
+		}
+		if src.FrontendRef != nil {
+			refs++
+			if src.FrontendRef.Name == page.Name {
+				errs = append(errs, field.Invalid(srcPath.Child("frontendRef", "name"), src.FrontendRef.Name, "must not reference itself"))
+			}
+		}
+		if refs != 1 {
+			errs = append(errs, field.Invalid(srcPath, "", "exactly one of configMapKeyRef, secretKeyRef and frontendRef must be set"))
+			continue
+		}
+
+		for _, msg := range validation.IsConfigMapKey(sourceKey(src)) {
+			errs = append(errs, field.Invalid(srcPath, sourceKey(src), msg))
+		}
+	}
+	return errs
+}
+
+// resolveContentSources fetches the content referenced in spec.contentsFrom, keyed by source key.
+func (r *FrontendReconciler) resolveContentSources(ctx context.Context, page *frontendv1beta1.Frontend) (map[string][]byte, error) {
+	if len(page.Spec.ContentsFrom) == 0 {
+		return nil, nil
+	}
+
+	contents := map[string][]byte{}
+	for _, src := range page.Spec.ContentsFrom {
+		data, found, err := r.resolveContentSource(ctx, page.Namespace, src)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			contents[sourceKey(src)] = data
+		}
+	}
+	return contents, nil
+}
+
+func (r *FrontendReconciler) resolveContentSource(ctx context.Context, namespace string, src frontendv1beta1.ContentSource) ([]byte, bool, error) {
+	var (
+		kind, name string
+		optional   bool
+		data       []byte
+		found      bool
+		err        error
+	)
+
+	switch {
+	case src.ConfigMapKeyRef != nil:
+		ref := src.ConfigMapKeyRef
+		kind, name, optional = "ConfigMap", ref.Name, ref.Optional != nil && *ref.Optional
+
+		var cm corev1.ConfigMap
+		if err = r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &cm); err == nil {
+			data, found = configMapValue(&cm, ref.Key)
+		}
+	case src.SecretKeyRef != nil:
+		ref := src.SecretKeyRef
+		kind, name, optional = "Secret", ref.Name, ref.Optional != nil && *ref.Optional
+
+		var secret corev1.Secret
+		if err = r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &secret); err == nil {
+			data, found = secret.Data[ref.Key]
+		}
+	case src.FrontendRef != nil:
+		ref := src.FrontendRef
+		kind, name = "Frontend", ref.Name
+
+		var other frontendv1beta1.Frontend
+		if err = r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &other); err == nil {
+			// Only inline content is shared, so Frontends cannot form reference cycles
+			data, found = configMapValue(buildConfigMap(&other, nil), ref.Key)
+		}
+	}
+
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, false, err
+	}
+	if !found && !optional {
+		return nil, false, missingSourceError{fmt.Errorf("key %q of %s %s/%s not found", sourceKey(src), kind, namespace, name)}
+	}
+	return data, found, nil
+}
+
+func configMapValue(cm *corev1.ConfigMap, key string) ([]byte, bool) {
+	if v, ok := cm.Data[key]; ok {
+		return []byte(v), true
+	}
+	v, ok := cm.BinaryData[key]
+	return v, ok
+}
+
+// contentSourceIndexers extract the names of the objects referenced in spec.contentsFrom.
+var contentSourceIndexers = map[string]client.IndexerFunc{
+	configMapSourceIndex: contentSourceNames(func(src frontendv1beta1.ContentSource) string {
+		if src.ConfigMapKeyRef != nil {
+			return src.ConfigMapKeyRef.Name
+		}
+		return ""
+	}),
+	secretSourceIndex: contentSourceNames(func(src frontendv1beta1.ContentSource) string {
+		if src.SecretKeyRef != nil {
+			return src.SecretKeyRef.Name
+		}
+		return ""
+	}),
+	frontendSourceIndex: contentSourceNames(func(src frontendv1beta1.ContentSource) string {
+		if src.FrontendRef != nil {
+			return src.FrontendRef.Name
+		}
+		return ""
+	}),
+}
+
+func contentSourceNames(name func(frontendv1beta1.ContentSource) string) client.IndexerFunc {
+	return func(obj client.Object) []string {
+		var names []string
+		for _, src := range obj.(*frontendv1beta1.Frontend).Spec.ContentsFrom {
+			if n := name(src); n != "" {
+				names = append(names, n)
+			}
+		}
+		return names
+	}
+}
+
+// indexContentSources registers the field indexes used to find the Frontends referencing an object.
+func indexContentSources(ctx context.Context, mgr ctrl.Manager) error {
+	for index, indexer := range contentSourceIndexers {
+		if err := mgr.GetFieldIndexer().IndexField(ctx, &frontendv1beta1.Frontend{}, index, indexer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// frontendsReferencing returns a handler mapping an object to the Frontends referencing it through index.
+func (r *FrontendReconciler) frontendsReferencing(index string) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []ctrl.Request {
+		var pages frontendv1beta1.FrontendList
+		if err := r.List(ctx, &pages, client.InNamespace(obj.GetNamespace()), client.MatchingFields{index: obj.GetName()}); err != nil {
+			log.Error().Err(err).Msgf("Failed to list Frontends referencing %s %s", obj.GetName(), obj.GetNamespace())
+			return nil
+		}
+
+		requests := make([]ctrl.Request, 0, len(pages.Items))
+		for _, p := range pages.Items {
+			requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&p)})
+		}
+		return requests
+	}
+}
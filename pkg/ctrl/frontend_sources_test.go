@@ -0,0 +1,129 @@
+package ctrl
+
+import (
+	context "context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	frontendv1beta1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
+)
+
+func newFakeReconciler(t *testing.T, objs ...client.Object) *FrontendReconciler {
+	t.Helper()
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+	require.NoError(t, frontendv1beta1.AddToScheme(s))
+
+	b := fake.NewClientBuilder().WithScheme(s).WithObjects(objs...).WithStatusSubresource(&frontendv1beta1.Frontend{})
+	for index, indexer := range contentSourceIndexers {
+		b = b.WithIndex(&frontendv1beta1.Frontend{}, index, indexer)
+	}
+	return &FrontendReconciler{Client: b.Build(), Scheme: s, Recorder: record.NewFakeRecorder(100)}
+}
+
+func TestResolveContentSources(t *testing.T) {
+	page := testFrontend()
+	page.Spec.ContentsFrom = []frontendv1beta1.ContentSource{
+		{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "ci-build"}, Key: "app.js"}},
+		{SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "ci-assets"}, Key: "logo.png"}, Path: "img/logo.png"},
+		{FrontendRef: &frontendv1beta1.FrontendKeySelector{Name: "shared", Key: "footer.html"}},
+	}
+	require.Empty(t, validateContent(page))
+
+	r := newFakeReconciler(t,
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "ci-build", Namespace: page.Namespace},
+			Data:       map[string]string{"app.js": "console.log('hi')"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "ci-assets", Namespace: page.Namespace},
+			Data:       map[string][]byte{"logo.png": {0x89, 'P', 'N', 'G', 0xff}},
+		},
+		&frontendv1beta1.Frontend{
+			ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: page.Namespace},
+			Spec: frontendv1beta1.FrontendSpec{
+				Files: map[string]frontendv1beta1.FrontendFile{"footer.html": {Content: "<footer/>"}},
+			},
+		},
+	)
+
+	sourced, err := r.resolveContentSources(context.Background(), page)
+	require.NoError(t, err)
+
+	cm := buildConfigMap(page, sourced)
+	require.Equal(t, "console.log('hi')", cm.Data["app.js"])
+	require.Equal(t, "<footer/>", cm.Data["footer.html"])
+	require.Equal(t, []byte{0x89, 'P', 'N', 'G', 0xff}, cm.BinaryData["logo.png"])
+	require.Equal(t, "hello world", cm.Data["contents"])
+
+	dep := buildDeployment(page, contentRevision(cm))
+	require.Contains(t, dep.Spec.Template.Spec.Volumes[0].ConfigMap.Items, corev1.KeyToPath{Key: "logo.png", Path: "img/logo.png"})
+}
+
+func TestResolveContentSources_Missing(t *testing.T) {
+	page := testFrontend()
+	optional := true
+	page.Spec.ContentsFrom = []frontendv1beta1.ContentSource{
+		{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "maybe"}, Key: "a.txt", Optional: &optional}},
+	}
+
+	r := newFakeReconciler(t)
+	sourced, err := r.resolveContentSources(context.Background(), page)
+	require.NoError(t, err, "optional sources may be missing")
+	require.Empty(t, sourced)
+
+	page.Spec.ContentsFrom[0].ConfigMapKeyRef.Optional = nil
+	_, err = r.resolveContentSources(context.Background(), page)
+	var sourceErr missingSourceError
+	require.True(t, errors.As(err, &sourceErr), "required sources must exist")
+}
+
+func TestFrontendsReferencing(t *testing.T) {
+	page := testFrontend()
+	page.Spec.ContentsFrom = []frontendv1beta1.ContentSource{
+		{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "ci-build"}, Key: "app.js"}},
+	}
+	other := testFrontend()
+	other.Name = "other-page"
+
+	r := newFakeReconciler(t, page, other)
+	requests := r.frontendsReferencing(configMapSourceIndex)(context.Background(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "ci-build", Namespace: page.Namespace},
+	})
+	require.Len(t, requests, 1)
+	require.Equal(t, page.Name, requests[0].Name)
+
+	requests = r.frontendsReferencing(secretSourceIndex)(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "ci-build", Namespace: page.Namespace},
+	})
+	require.Empty(t, requests)
+}
+
+func TestValidateContentSources(t *testing.T) {
+	page := testFrontend()
+	page.Spec.Files = map[string]frontendv1beta1.FrontendFile{"app.js": {Content: "x"}}
+	page.Spec.ContentsFrom = []frontendv1beta1.ContentSource{
+		{},
+		{FrontendRef: &frontendv1beta1.FrontendKeySelector{Name: page.Name, Key: "x"}},
+	}
+	errs := validateContent(page)
+	require.Len(t, errs, 2)
+	require.Equal(t, "spec.contentsFrom[0]", errs[0].Field)
+	require.Equal(t, "spec.contentsFrom[1].frontendRef.name", errs[1].Field)
+
+	page.Spec.ContentsFrom = []frontendv1beta1.ContentSource{
+		{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "ci"}, Key: "app.js"}},
+	}
+	errs = validateContent(page)
+	require.Len(t, errs, 1)
+	require.Equal(t, "spec.contentsFrom[0]", errs[0].Field)
+}
@@ -0,0 +1,152 @@
+package ctrl
+
+import (
+	context "context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	frontendv1beta1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
+)
+
+// contentRevision returns a short, stable hash of the data rendered into the Frontend ConfigMap
+// and the paths it is mounted at.
+func contentRevision(cm *corev1.ConfigMap) string {
+	h := sha256.New()
+	if items, ok := cm.Annotations[itemsAnnotation]; ok {
+		fmt.Fprintf(h, "items=%s\n", items)
+	}
+	for _, k := range slices.Sorted(maps.Keys(cm.Data)) {
+		fmt.Fprintf(h, "%s=%q\n", k, cm.Data[k])
+	}
+	for _, k := range slices.Sorted(maps.Keys(cm.BinaryData)) {
+		fmt.Fprintf(h, "%s=%x\n", k, cm.BinaryData[k])
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// computeStatus derives the Frontend status from the owned Deployment and the
+// result of the last reconcile. dep may be nil if the Deployment does not exist yet.
+func computeStatus(page *frontendv1beta1.Frontend, dep *appsv1.Deployment, revision string, reconcileErr error) frontendv1beta1.FrontendStatus {
+	status := *page.Status.DeepCopy()
+	status.ObservedGeneration = page.Generation
+	status.ContentRevision = revision
+	// The scale subresource reads the pod selector from the status
+	status.Selector = labels.SelectorFromSet(labels.Set{"app": page.Name}).String()
+
+	setCondition := func(condType string, condStatus metav1.ConditionStatus, reason, message string) {
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               condType,
+			Status:             condStatus,
+			Reason:             reason,
+			Message:            message,
+			ObservedGeneration: page.Generation,
+		})
+	}
+
+	if dep == nil {
+		status.Replicas = 0
+		status.ReadyReplicas = 0
+		setCondition(frontendv1beta1.ConditionProgressing, metav1.ConditionTrue, "DeploymentPending", "Deployment has not been created yet")
+	} else {
+		status.Replicas = dep.Status.Replicas
+		status.ReadyReplicas = dep.Status.ReadyReplicas
+
+		desired := int32(1)
+		if dep.Spec.Replicas != nil {
+			desired = *dep.Spec.Replicas
+		}
+
+		switch {
+		case dep.Generation > dep.Status.ObservedGeneration:
+			setCondition(frontendv1beta1.ConditionProgressing, metav1.ConditionTrue, "RollingOut", "Deployment spec change has not been observed yet")
+		case dep.Status.UpdatedReplicas < desired || dep.Status.Replicas > dep.Status.UpdatedReplicas:
+			setCondition(frontendv1beta1.ConditionProgressing, metav1.ConditionTrue, "RollingOut",
+				fmt.Sprintf("%d of %d replicas updated", dep.Status.UpdatedReplicas, desired))
+		default:
+			setCondition(frontendv1beta1.ConditionProgressing, metav1.ConditionFalse, "RolloutComplete", "Deployment is up to date")
+		}
+	}
+
+	var replicaFailure, progress *appsv1.DeploymentCondition
+	if dep != nil {
+		replicaFailure = deploymentCondition(dep, appsv1.DeploymentReplicaFailure)
+		progress = deploymentCondition(dep, appsv1.DeploymentProgressing)
+	}
+
+	var specErr invalidSpecError
+	var sourceErr missingSourceError
+	var cleanupErr cleanupError
+	var conflictErr ownershipConflictError
+	switch {
+	case errors.As(reconcileErr, &cleanupErr):
+		setCondition(frontendv1beta1.ConditionDegraded, metav1.ConditionTrue, "CleanupFailed", reconcileErr.Error())
+	case errors.As(reconcileErr, &specErr):
+		setCondition(frontendv1beta1.ConditionDegraded, metav1.ConditionTrue, "InvalidSpec", reconcileErr.Error())
+	case errors.As(reconcileErr, &sourceErr):
+		setCondition(frontendv1beta1.ConditionDegraded, metav1.ConditionTrue, "ContentSourceNotFound", reconcileErr.Error())
+	case errors.As(reconcileErr, &conflictErr):
+		setCondition(frontendv1beta1.ConditionDegraded, metav1.ConditionTrue, "OwnershipConflict", reconcileErr.Error())
+	case reconcileErr != nil:
+		setCondition(frontendv1beta1.ConditionDegraded, metav1.ConditionTrue, "ReconcileError", reconcileErr.Error())
+	case replicaFailure != nil && replicaFailure.Status == corev1.ConditionTrue:
+		setCondition(frontendv1beta1.ConditionDegraded, metav1.ConditionTrue, "ReplicaFailure", replicaFailure.Message)
+	case progress != nil && progress.Reason == "ProgressDeadlineExceeded":
+		setCondition(frontendv1beta1.ConditionDegraded, metav1.ConditionTrue, "ProgressDeadlineExceeded", progress.Message)
+	default:
+		setCondition(frontendv1beta1.ConditionDegraded, metav1.ConditionFalse, "AsExpected", "")
+	}
+
+	switch {
+	case meta.IsStatusConditionTrue(status.Conditions, frontendv1beta1.ConditionDegraded):
+		setCondition(frontendv1beta1.ConditionReady, metav1.ConditionFalse, "Degraded", "Frontend is degraded")
+	case page.Spec.Suspend:
+		setCondition(frontendv1beta1.ConditionReady, metav1.ConditionFalse, "Suspended", "Frontend is scaled down to zero")
+	case meta.IsStatusConditionTrue(status.Conditions, frontendv1beta1.ConditionProgressing):
+		setCondition(frontendv1beta1.ConditionReady, metav1.ConditionFalse, "RollingOut", "Deployment rollout is in progress")
+	case dep != nil && dep.Spec.Replicas != nil && dep.Status.ReadyReplicas < *dep.Spec.Replicas:
+		setCondition(frontendv1beta1.ConditionReady, metav1.ConditionFalse, "DeploymentNotReady",
+			fmt.Sprintf("%d of %d replicas ready", dep.Status.ReadyReplicas, *dep.Spec.Replicas))
+	default:
+		setCondition(frontendv1beta1.ConditionReady, metav1.ConditionTrue, "DeploymentReady", "All replicas are ready")
+	}
+
+	setPausedCondition(&status, page)
+	return status
+}
+
+// updateStatus patches the Frontend status if it differs from the observed one.
+func (r *FrontendReconciler) updateStatus(ctx context.Context, page *frontendv1beta1.Frontend, status frontendv1beta1.FrontendStatus) error {
+	if reflect.DeepEqual(page.Status, status) {
+		recordStatus(page, nil, status)
+		return nil
+	}
+	previous := page.DeepCopy()
+	patch := client.MergeFrom(previous)
+	page.Status = status
+	if err := r.Status().Patch(ctx, page, patch); err != nil {
+		return err
+	}
+	recordStatus(page, &previous.Status, status)
+	return nil
+}
+
+func deploymentCondition(dep *appsv1.Deployment, condType appsv1.DeploymentConditionType) *appsv1.DeploymentCondition {
+	for i := range dep.Status.Conditions {
+		if dep.Status.Conditions[i].Type == condType {
+			return &dep.Status.Conditions[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package ctrl
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	frontendv1beta1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
+)
+
+func testFrontend() *frontendv1beta1.Frontend {
+	return &frontendv1beta1.Frontend{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-page", Namespace: "default", Generation: 3},
+		Spec: frontendv1beta1.FrontendSpec{
+			Files:    map[string]frontendv1beta1.FrontendFile{"contents": {Content: "hello world"}},
+			Image:    "nginx:alpine",
+			Replicas: int32Ptr(2),
+		},
+	}
+}
+
+func requireCondition(t *testing.T, status frontendv1beta1.FrontendStatus, condType string, want metav1.ConditionStatus, reason string) {
+	t.Helper()
+	c := meta.FindStatusCondition(status.Conditions, condType)
+	require.NotNil(t, c, "condition %s should be set", condType)
+	require.Equal(t, want, c.Status, "condition %s status", condType)
+	require.Equal(t, reason, c.Reason, "condition %s reason", condType)
+}
+
+func TestContentRevision_Stable(t *testing.T) {
+	page := testFrontend()
+	rev := contentRevision(buildConfigMap(page, nil))
+	require.Len(t, rev, 16)
+	require.Equal(t, rev, contentRevision(buildConfigMap(page, nil)))
+
+	page.Spec.Files["contents"] = frontendv1beta1.FrontendFile{Content: "updated!"}
+	require.NotEqual(t, rev, contentRevision(buildConfigMap(page, nil)))
+}
+
+func TestComputeStatus_DeploymentReady(t *testing.T) {
+	page := testFrontend()
+	dep := buildDeployment(page, "abc")
+	dep.Generation = 2
+	dep.Status = appsv1.DeploymentStatus{
+		ObservedGeneration: 2,
+		Replicas:           2,
+		UpdatedReplicas:    2,
+		ReadyReplicas:      2,
+	}
+
+	status := computeStatus(page, dep, "abc", nil)
+	require.Equal(t, int64(3), status.ObservedGeneration)
+	require.Equal(t, int32(2), status.Replicas)
+	require.Equal(t, int32(2), status.ReadyReplicas)
+	require.Equal(t, "abc", status.ContentRevision)
+	require.Equal(t, "app="+page.Name, status.Selector)
+	requireCondition(t, status, frontendv1beta1.ConditionReady, metav1.ConditionTrue, "DeploymentReady")
+	requireCondition(t, status, frontendv1beta1.ConditionProgressing, metav1.ConditionFalse, "RolloutComplete")
+	requireCondition(t, status, frontendv1beta1.ConditionDegraded, metav1.ConditionFalse, "AsExpected")
+}
+
+func TestComputeStatus_RollingOut(t *testing.T) {
+	page := testFrontend()
+	dep := buildDeployment(page, "abc")
+	dep.Generation = 2
+	dep.Status = appsv1.DeploymentStatus{
+		ObservedGeneration: 2,
+		Replicas:           3,
+		UpdatedReplicas:    1,
+		ReadyReplicas:      2,
+	}
+
+	status := computeStatus(page, dep, "abc", nil)
+	requireCondition(t, status, frontendv1beta1.ConditionReady, metav1.ConditionFalse, "RollingOut")
+	requireCondition(t, status, frontendv1beta1.ConditionProgressing, metav1.ConditionTrue, "RollingOut")
+}
+
+func TestComputeStatus_Degraded(t *testing.T) {
+	page := testFrontend()
+	dep := buildDeployment(page, "abc")
+	dep.Status.Conditions = []appsv1.DeploymentCondition{{
+		Type:    appsv1.DeploymentProgressing,
+		Status:  corev1.ConditionFalse,
+		Reason:  "ProgressDeadlineExceeded",
+		Message: "timed out",
+	}}
+
+	status := computeStatus(page, dep, "abc", nil)
+	requireCondition(t, status, frontendv1beta1.ConditionDegraded, metav1.ConditionTrue, "ProgressDeadlineExceeded")
+	requireCondition(t, status, frontendv1beta1.ConditionReady, metav1.ConditionFalse, "Degraded")
+
+	status = computeStatus(page, nil, "abc", errors.New("boom"))
+	requireCondition(t, status, frontendv1beta1.ConditionDegraded, metav1.ConditionTrue, "ReconcileError")
+	requireCondition(t, status, frontendv1beta1.ConditionProgressing, metav1.ConditionTrue, "DeploymentPending")
+
+	status = computeStatus(page, nil, "abc", ownershipConflictError{errors.New("ConfigMap test-page already exists")})
+	requireCondition(t, status, frontendv1beta1.ConditionDegraded, metav1.ConditionTrue, "OwnershipConflict")
+}
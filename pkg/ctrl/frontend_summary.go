@@ -0,0 +1,173 @@
+package ctrl
+
+import (
+	context "context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// specHashAnnotation records the hash of the desired state last applied to a child object, so
+// that applying it again can be skipped.
+const specHashAnnotation = "frontend.oleksandr-san.io/spec-hash"
+
+// reconcileSummary collects what a single reconcile of a Frontend did, so that it is logged
+// as one line instead of one line per child object.
+type reconcileSummary struct {
+	start time.Time
+	// changes lists the child objects that were created, updated or deleted.
+	changes []string
+	// unchanged counts the child objects that were already up to date.
+	unchanged int
+	// retried is the conflict or transient error the reconcile is retried after.
+	retried error
+}
+
+type reconcileSummaryKey struct{}
+
+func withReconcileSummary(ctx context.Context, summary *reconcileSummary) context.Context {
+	return context.WithValue(ctx, reconcileSummaryKey{}, summary)
+}
+
+// summaryFrom returns the summary of the reconcile running in ctx. Outside of a reconcile the
+// returned summary is discarded.
+func summaryFrom(ctx context.Context) *reconcileSummary {
+	if summary, ok := ctx.Value(reconcileSummaryKey{}).(*reconcileSummary); ok {
+		return summary
+	}
+	return &reconcileSummary{}
+}
+
+func (s *reconcileSummary) changed(action, kind, name string) {
+	recordChildOperation(action, kind)
+	s.changes = append(s.changes, fmt.Sprintf("%s %s %s", action, kind, name))
+}
+
+// log writes the summary at info level if anything changed or is retried, and at debug level
+// for reconciles that found everything up to date.
+func (s *reconcileSummary) log(req ctrl.Request, result ctrl.Result, err error) {
+	var event *zerolog.Event
+	switch {
+	case err != nil:
+		event = log.Error().Err(err)
+	case s.retried != nil:
+		event = log.Info().Err(s.retried)
+	case len(s.changes) > 0:
+		event = log.Info()
+	default:
+		event = log.Debug()
+	}
+	event = event.
+		Str("frontend", req.Name).
+		Str("namespace", req.Namespace).
+		Dur("duration", time.Since(s.start)).
+		Strs("changes", s.changes).
+		Int("unchanged", s.unchanged)
+	if result.RequeueAfter > 0 {
+		event = event.Dur("requeueAfter", result.RequeueAfter)
+	}
+	event.Msg("Reconciled Frontend")
+}
+
+// objectHash returns a short hash of the desired state of obj.
+func objectHash(obj client.Object) string {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// upToDate reports whether existing was last applied from desired and still has every field
+// set in desired. Fields set by the API server or other managers are ignored, like they are
+// by server-side apply.
+func upToDate(desired, existing client.Object) bool {
+	hash := desired.GetAnnotations()[specHashAnnotation]
+	if hash == "" || existing.GetAnnotations()[specHashAnnotation] != hash {
+		return false
+	}
+	d, e, err := appliedFields(desired, existing)
+	if err != nil {
+		return false
+	}
+	return isSubset(d, e)
+}
+
+// driftedFields returns the paths of the fields set in desired that have another value in
+// existing, such as spec.template.spec.containers.
+func driftedFields(desired, existing client.Object) []string {
+	d, e, err := appliedFields(desired, existing)
+	if err != nil {
+		return nil
+	}
+	return diffFields(d, e, "", maxDriftDepth)
+}
+
+// appliedFields converts desired and existing to unstructured content, without the fields of
+// desired that are never applied.
+func appliedFields(desired, existing client.Object) (map[string]any, map[string]any, error) {
+	d, err := runtime.DefaultUnstructuredConverter.ToUnstructured(desired)
+	if err != nil {
+		return nil, nil, err
+	}
+	e, err := runtime.DefaultUnstructuredConverter.ToUnstructured(existing)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Objects read from the cache have no type meta, and most metadata is set by the API server
+	delete(d, "apiVersion")
+	delete(d, "kind")
+	delete(d, "status")
+	if metadata, ok := d["metadata"].(map[string]any); ok {
+		d["metadata"] = map[string]any{
+			"labels":          metadata["labels"],
+			"annotations":     metadata["annotations"],
+			"ownerReferences": metadata["ownerReferences"],
+		}
+	}
+	return d, e, nil
+}
+
+// isSubset reports whether every field set in desired has the same value in existing. Lists
+// must match element by element.
+func isSubset(desired, existing any) bool {
+	switch d := desired.(type) {
+	case nil:
+		return true
+	case map[string]any:
+		e, ok := existing.(map[string]any)
+		if !ok {
+			return false
+		}
+		for k, v := range d {
+			if !isSubset(v, e[k]) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		e, ok := existing.([]any)
+		if !ok || len(d) != len(e) {
+			return false
+		}
+		for i := range d {
+			if !isSubset(d[i], e[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return desired == existing
+	}
+}
@@ -0,0 +1,52 @@
+package ctrl
+
+import (
+	context "context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUpToDate(t *testing.T) {
+	page := testFrontend()
+	page.Spec.PodTemplate = nil
+	desired := buildDeployment(page, "abc")
+	desired.Spec.Template.Spec.Containers[0].Resources.Limits = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("0.5Gi")}
+	desired.SetAnnotations(map[string]string{specHashAnnotation: objectHash(desired)})
+
+	// The API server sets defaults, metadata and the status
+	existing := desired.DeepCopy()
+	existing.TypeMeta = metav1.TypeMeta{}
+	existing.CreationTimestamp = metav1.Now()
+	existing.ResourceVersion = "42"
+	existing.Spec.RevisionHistoryLimit = int32Ptr(10)
+	existing.Spec.Template.Spec.Containers[0].TerminationMessagePath = corev1.TerminationMessagePathDefault
+	existing.Spec.Template.Spec.Containers[0].Resources.Limits = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")}
+	existing.Status.ReadyReplicas = 2
+	require.True(t, upToDate(desired, existing))
+
+	drifted := existing.DeepCopy()
+	drifted.Spec.Template.Spec.Containers[0].Image = "nginx:latest"
+	require.False(t, upToDate(desired, drifted), "changes made by others to applied fields should be reverted")
+
+	outdated := existing.DeepCopy()
+	outdated.Annotations[specHashAnnotation] = "other"
+	require.False(t, upToDate(desired, outdated), "objects applied from another desired state should be applied again")
+}
+
+func TestReconcileSummary(t *testing.T) {
+	summary := &reconcileSummary{}
+	ctx := withReconcileSummary(context.Background(), summary)
+
+	summaryFrom(ctx).changed("Created", "Deployment", "test-page")
+	summaryFrom(ctx).unchanged++
+	require.Equal(t, []string{"Created Deployment test-page"}, summary.changes)
+	require.Equal(t, 1, summary.unchanged)
+
+	// Changes made outside of a reconcile are discarded
+	summaryFrom(context.Background()).changed("Deleted", "Service", "test-page")
+	require.Len(t, summary.changes, 1)
+}
@@ -7,9 +7,10 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	frontendv1alpha1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1alpha1"
+	frontendv1beta1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
 	testutil "github.com/oleksandr-san/k8s-controller/pkg/testutil"
 	"github.com/stretchr/testify/require"
 	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
@@ -19,7 +20,7 @@ import (
 )
 
 func printTableState(ctx context.Context, c client.Client, ns string, t *testing.T, step string) {
-	var pages frontendv1alpha1.FrontendList
+	var pages frontendv1beta1.FrontendList
 	var cms corev1.ConfigMapList
 	var deps appsv1.DeploymentList
 
@@ -30,7 +31,7 @@ func printTableState(ctx context.Context, c client.Client, ns string, t *testing
 	t.Logf("\n==== ETCD STATE (%s) ====", step)
 	t.Logf("%-15s %-15s %-10s %-10s", "KIND", "NAME", "NAMESPACE", "EXTRA")
 	for _, p := range pages.Items {
-		t.Logf("%-15s %-15s %-10s contents=%.10s", "Frontend", p.Name, p.Namespace, p.Spec.Contents)
+		t.Logf("%-15s %-15s %-10s contents=%.10s", "Frontend", p.Name, p.Namespace, p.Spec.Files["contents"].Content)
 	}
 	for _, cm := range cms.Items {
 		contents := cm.Data["contents"]
@@ -51,9 +52,11 @@ func printTableState(ctx context.Context, c client.Client, ns string, t *testing
 func TestFrontendReconciler_CreateFlow(t *testing.T) {
 	log.SetLogger(zap.New(zap.UseDevMode(true)))
 
-	_, k8sClient, restCfg, cleanup := testutil.StartTestManager(t)
+	mgr, k8sClient, restCfg, cleanup := testutil.StartTestManager(t)
 	defer cleanup()
 
+	require.NoError(t, AddFrontendController(mgr, DefaultFrontendOptions()))
+
 	ctx := context.Background()
 	ns := "default"
 
@@ -66,15 +69,15 @@ func TestFrontendReconciler_CreateFlow(t *testing.T) {
 
 	printTableState(ctx, k8sClient, ns, t, "initial")
 
-	page := &frontendv1alpha1.Frontend{
+	page := &frontendv1beta1.Frontend{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "test-page",
 			Namespace: ns,
 		},
-		Spec: frontendv1alpha1.FrontendSpec{
-			Contents: "hello world",
+		Spec: frontendv1beta1.FrontendSpec{
+			Files:    map[string]frontendv1beta1.FrontendFile{"contents": {Content: "hello world"}},
 			Image:    "nginx:alpine",
-			Replicas: 1,
+			Replicas: int32Ptr(1),
 		},
 	}
 	if err := k8sClient.Create(ctx, page); err != nil {
@@ -85,20 +88,32 @@ func TestFrontendReconciler_CreateFlow(t *testing.T) {
 	printTableState(ctx, k8sClient, ns, t, "after create")
 
 	// 2. List and check the CR is present
-	var pageList frontendv1alpha1.FrontendList
+	var pageList frontendv1beta1.FrontendList
 	err = k8sClient.List(ctx, &pageList, client.InNamespace(ns))
 	require.NoError(t, err)
 	require.NotEmpty(t, pageList.Items, "Should find at least one Frontend")
 	found := false
 	for _, p := range pageList.Items {
-		if p.Name == "test-page" && p.Spec.Contents == "hello world" {
+		if p.Name == "test-page" && p.Spec.Files["contents"].Content == "hello world" {
 			found = true
 		}
 	}
 	require.True(t, found, "Created Frontend should be present and correct")
 
+	// 3. Status is reported from the owned Deployment
+	require.Eventually(t, func() bool {
+		var got frontendv1beta1.Frontend
+		if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(page), &got); err != nil {
+			return false
+		}
+		return got.Status.ObservedGeneration == got.Generation &&
+			got.Status.ContentRevision != "" &&
+			meta.FindStatusCondition(got.Status.Conditions, frontendv1beta1.ConditionReady) != nil
+	}, 10*time.Second, 100*time.Millisecond, "Frontend status should be reported")
+
 	// Update
-	page.Spec.Contents = "updated!"
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(page), page))
+	page.Spec.Files["contents"] = frontendv1beta1.FrontendFile{Content: "updated!"}
 	if err := k8sClient.Update(ctx, page); err != nil {
 		t.Fatalf("Failed to update Frontend: %v", err)
 	}
@@ -112,3 +127,82 @@ func TestFrontendReconciler_CreateFlow(t *testing.T) {
 	time.Sleep(1 * time.Second)
 	printTableState(ctx, k8sClient, ns, t, "after delete")
 }
+
+func TestBuildDeployment_ContentRevisionAnnotation(t *testing.T) {
+	page := &frontendv1beta1.Frontend{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-page", Namespace: "default"},
+		Spec: frontendv1beta1.FrontendSpec{
+			Files:    map[string]frontendv1beta1.FrontendFile{"contents": {Content: "hello world"}},
+			Image:    "nginx:alpine",
+			Replicas: int32Ptr(1),
+		},
+	}
+
+	dep := buildDeployment(page, contentRevision(buildConfigMap(page, nil)))
+	rev := dep.Spec.Template.Annotations[contentRevisionAnnotation]
+	require.NotEmpty(t, rev, "pod template should carry the content revision")
+
+	page.Spec.Files["contents"] = frontendv1beta1.FrontendFile{Content: "updated!"}
+	dep = buildDeployment(page, contentRevision(buildConfigMap(page, nil)))
+	require.NotEqual(t, rev, dep.Spec.Template.Annotations[contentRevisionAnnotation], "content change should change the pod template")
+
+	page.Spec.ContentUpdatePolicy = frontendv1beta1.ContentUpdateInPlace
+	dep = buildDeployment(page, contentRevision(buildConfigMap(page, nil)))
+	require.NotContains(t, dep.Spec.Template.Annotations, contentRevisionAnnotation, "InPlace policy should not roll pods")
+}
+
+func TestFrontendReconciler_DriftCorrection(t *testing.T) {
+	mgr, k8sClient, _, cleanup := testutil.StartTestManager(t)
+	defer cleanup()
+
+	require.NoError(t, AddFrontendController(mgr, DefaultFrontendOptions()))
+
+	ctx := context.Background()
+	page := &frontendv1beta1.Frontend{
+		ObjectMeta: metav1.ObjectMeta{Name: "drift-page", Namespace: "default"},
+		Spec: frontendv1beta1.FrontendSpec{
+			Files:    map[string]frontendv1beta1.FrontendFile{"contents": {Content: "hello world"}},
+			Image:    "nginx:alpine",
+			Replicas: int32Ptr(1),
+		},
+	}
+	require.NoError(t, k8sClient.Create(ctx, page))
+
+	var dep appsv1.Deployment
+	require.Eventually(t, func() bool {
+		return k8sClient.Get(ctx, client.ObjectKeyFromObject(page), &dep) == nil
+	}, 10*time.Second, 100*time.Millisecond, "Deployment should be created")
+
+	// Someone edits the volume mount and the image by hand
+	dep.Spec.Template.Spec.Containers[0].VolumeMounts[0].MountPath = "/tmp"
+	dep.Spec.Template.Spec.Containers[0].Image = "nginx:latest"
+	require.NoError(t, k8sClient.Update(ctx, &dep, client.FieldOwner("kubectl-edit")))
+
+	require.Eventually(t, func() bool {
+		if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(page), &dep); err != nil {
+			return false
+		}
+		return dep.Spec.Template.Spec.Containers[0].VolumeMounts[0].MountPath == "/data" &&
+			dep.Spec.Template.Spec.Containers[0].Image == "nginx:alpine"
+	}, 10*time.Second, 100*time.Millisecond, "drift should be corrected")
+}
+
+func TestReplicasManagedExternally(t *testing.T) {
+	dep := &appsv1.Deployment{}
+	require.False(t, replicasManagedExternally(dep))
+
+	dep.ManagedFields = []metav1.ManagedFieldsEntry{{
+		Manager:   fieldManager,
+		Operation: metav1.ManagedFieldsOperationApply,
+		FieldsV1:  &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:replicas":{},"f:template":{}}}`)},
+	}}
+	require.False(t, replicasManagedExternally(dep), "our own replicas should not count as external")
+
+	dep.ManagedFields = append(dep.ManagedFields, metav1.ManagedFieldsEntry{
+		Manager:     "kube-controller-manager",
+		Operation:   metav1.ManagedFieldsOperationUpdate,
+		Subresource: "scale",
+		FieldsV1:    &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:replicas":{}}}`)},
+	})
+	require.True(t, replicasManagedExternally(dep), "replicas scaled through the scale subresource are external")
+}
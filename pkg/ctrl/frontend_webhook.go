@@ -0,0 +1,311 @@
+package ctrl
+
+import (
+	context "context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	frontendv1beta1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
+)
+
+const (
+	// defaultImage is used for Frontends that do not specify an image. It runs as non-root,
+	// as required by the default security context of the pods.
+	defaultImage    = "nginxinc/nginx-unprivileged:alpine"
+	defaultReplicas = 1
+)
+
+// +kubebuilder:webhook:path=/mutate-frontend-oleksandr-san-io-v1beta1-frontend,mutating=true,failurePolicy=fail,sideEffects=None,groups=frontend.oleksandr-san.io,resources=frontends,verbs=create;update,versions=v1beta1,name=mfrontend.oleksandr-san.io,matchPolicy=Equivalent,admissionReviewVersions=v1
+// +kubebuilder:webhook:path=/validate-frontend-oleksandr-san-io-v1beta1-frontend,mutating=false,failurePolicy=fail,sideEffects=None,groups=frontend.oleksandr-san.io,resources=frontends,verbs=create;update,versions=v1beta1,name=vfrontend.oleksandr-san.io,matchPolicy=Equivalent,admissionReviewVersions=v1
+
+// FrontendWebhook defaults and validates Frontends on admission.
+type FrontendWebhook struct{}
+
+var (
+	_ admission.CustomDefaulter = &FrontendWebhook{}
+	_ admission.CustomValidator = &FrontendWebhook{}
+)
+
+func (w *FrontendWebhook) Default(ctx context.Context, obj runtime.Object) error {
+	page, ok := obj.(*frontendv1beta1.Frontend)
+	if !ok {
+		return fmt.Errorf("expected a Frontend but got %T", obj)
+	}
+	defaultFrontend(page)
+	return nil
+}
+
+func (w *FrontendWebhook) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return w.validate(obj)
+}
+
+func (w *FrontendWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	return w.validate(newObj)
+}
+
+func (w *FrontendWebhook) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (w *FrontendWebhook) validate(obj runtime.Object) (admission.Warnings, error) {
+	page, ok := obj.(*frontendv1beta1.Frontend)
+	if !ok {
+		return nil, fmt.Errorf("expected a Frontend but got %T", obj)
+	}
+	if errs := validateFrontend(page); len(errs) > 0 {
+		return nil, apierrors.NewInvalid(frontendv1beta1.SchemeGroupVersion.WithKind("Frontend").GroupKind(), page.Name, errs)
+	}
+	return nil, nil
+}
+
+// defaultFrontend fills in the optional fields of the Frontend spec.
+func defaultFrontend(page *frontendv1beta1.Frontend) {
+	spec := &page.Spec
+	if spec.Image == "" {
+		spec.Image = defaultImage
+	}
+	if spec.Replicas == nil {
+		replicas := int32(defaultReplicas)
+		spec.Replicas = &replicas
+	}
+	if spec.ContentUpdatePolicy == "" {
+		spec.ContentUpdatePolicy = frontendv1beta1.ContentUpdateRollout
+	}
+	if spec.MountPath == "" {
+		spec.MountPath = defaultMountPath
+	}
+	if spec.DeletionPolicy == "" {
+		spec.DeletionPolicy = frontendv1beta1.DeletionDelete
+	}
+	if spec.RevisionHistoryLimit == nil {
+		limit := int32(defaultRevisionHistoryLimit)
+		spec.RevisionHistoryLimit = &limit
+	}
+	if spec.Autoscaling != nil && spec.Autoscaling.MinReplicas == nil {
+		minReplicas := int32(1)
+		spec.Autoscaling.MinReplicas = &minReplicas
+	}
+	if spec.RolloutStrategy != nil && spec.RolloutStrategy.Type == "" {
+		spec.RolloutStrategy.Type = frontendv1beta1.RolloutRollingUpdate
+	}
+	if spec.Service != nil {
+		if spec.Service.Type == "" {
+			spec.Service.Type = corev1.ServiceTypeClusterIP
+		}
+		if spec.Service.Port == 0 {
+			spec.Service.Port = defaultServicePort
+		}
+	}
+	if spec.Ingress != nil && spec.Ingress.Path == "" {
+		spec.Ingress.Path = defaultIngressPath
+	}
+}
+
+// validateFrontend checks the Frontend spec can be rendered into valid child objects.
+func validateFrontend(page *frontendv1beta1.Frontend) field.ErrorList {
+	var errs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	// The name is reused for the Service, which requires a DNS-1035 label
+	for _, msg := range validation.IsDNS1035Label(page.Name) {
+		errs = append(errs, field.Invalid(field.NewPath("metadata", "name"), page.Name, msg))
+	}
+	if strings.HasSuffix(page.Name, canarySuffix) {
+		errs = append(errs, field.Invalid(field.NewPath("metadata", "name"), page.Name, "must not end with "+canarySuffix+", which names the canary Deployments"))
+	}
+
+	if strings.TrimSpace(page.Spec.Image) == "" {
+		errs = append(errs, field.Required(specPath.Child("image"), ""))
+	} else if strings.ContainsAny(page.Spec.Image, " \t\n") {
+		errs = append(errs, field.Invalid(specPath.Child("image"), page.Spec.Image, "must not contain whitespace"))
+	}
+	if page.Spec.Replicas != nil && *page.Spec.Replicas < 0 {
+		errs = append(errs, field.Invalid(specPath.Child("replicas"), *page.Spec.Replicas, "must be greater than or equal to 0"))
+	}
+	if page.Spec.RevisionHistoryLimit != nil && *page.Spec.RevisionHistoryLimit < 0 {
+		errs = append(errs, field.Invalid(specPath.Child("revisionHistoryLimit"), *page.Spec.RevisionHistoryLimit, "must be greater than or equal to 0"))
+	}
+	if rev := page.Spec.RollbackTo; rev != "" && !revisionPattern.MatchString(rev) {
+		errs = append(errs, field.Invalid(specPath.Child("rollbackTo"), rev, "must be a content revision of 16 hexadecimal digits"))
+	}
+	if as := page.Spec.Autoscaling; as != nil {
+		asPath := specPath.Child("autoscaling")
+		if as.MinReplicas != nil && *as.MinReplicas < 1 {
+			errs = append(errs, field.Invalid(asPath.Child("minReplicas"), *as.MinReplicas, "must be greater than or equal to 1"))
+		}
+		if as.MaxReplicas < 1 {
+			errs = append(errs, field.Invalid(asPath.Child("maxReplicas"), as.MaxReplicas, "must be greater than or equal to 1"))
+		} else if as.MinReplicas != nil && as.MaxReplicas < *as.MinReplicas {
+			errs = append(errs, field.Invalid(asPath.Child("maxReplicas"), as.MaxReplicas, "must be greater than or equal to minReplicas"))
+		}
+		if as.TargetCPUUtilizationPercentage != nil && *as.TargetCPUUtilizationPercentage < 1 {
+			errs = append(errs, field.Invalid(asPath.Child("targetCPUUtilizationPercentage"), *as.TargetCPUUtilizationPercentage, "must be greater than or equal to 1"))
+		}
+		if as.TargetMemoryUtilizationPercentage != nil && *as.TargetMemoryUtilizationPercentage < 1 {
+			errs = append(errs, field.Invalid(asPath.Child("targetMemoryUtilizationPercentage"), *as.TargetMemoryUtilizationPercentage, "must be greater than or equal to 1"))
+		}
+	}
+	if tmpl := page.Spec.PodTemplate; tmpl != nil {
+		tmplPath := specPath.Child("podTemplate")
+		errs = append(errs, metav1validation.ValidateLabels(tmpl.Labels, tmplPath.Child("labels"))...)
+		if _, ok := tmpl.Labels["app"]; ok {
+			errs = append(errs, field.Forbidden(tmplPath.Child("labels").Key("app"), "reserved for the pod selector"))
+		}
+		if _, ok := tmpl.Annotations[contentRevisionAnnotation]; ok {
+			errs = append(errs, field.Forbidden(tmplPath.Child("annotations").Key(contentRevisionAnnotation), "reserved for the controller"))
+		}
+	}
+	if page.Spec.MountPath == tmpMountPath {
+		errs = append(errs, field.Invalid(specPath.Child("mountPath"), page.Spec.MountPath, "must not be the writable scratch directory"))
+	}
+	switch page.Spec.ContentUpdatePolicy {
+	case "", frontendv1beta1.ContentUpdateRollout, frontendv1beta1.ContentUpdateInPlace:
+	default:
+		errs = append(errs, field.NotSupported(specPath.Child("contentUpdatePolicy"), page.Spec.ContentUpdatePolicy,
+			[]frontendv1beta1.ContentUpdatePolicy{frontendv1beta1.ContentUpdateRollout, frontendv1beta1.ContentUpdateInPlace}))
+	}
+
+	if page.Spec.RolloutStrategy != nil {
+		errs = append(errs, validateRolloutStrategy(page, specPath.Child("rolloutStrategy"))...)
+	}
+
+	switch page.Spec.DeletionPolicy {
+	case "", frontendv1beta1.DeletionDelete, frontendv1beta1.DeletionOrphan, frontendv1beta1.DeletionRetain:
+	default:
+		errs = append(errs, field.NotSupported(specPath.Child("deletionPolicy"), page.Spec.DeletionPolicy,
+			[]frontendv1beta1.DeletionPolicy{frontendv1beta1.DeletionDelete, frontendv1beta1.DeletionOrphan, frontendv1beta1.DeletionRetain}))
+	}
+
+	errs = append(errs, validateContent(page)...)
+	if err := validateContentSize(buildConfigMap(page, nil)); err != nil {
+		errs = append(errs, err)
+	}
+
+	if svc := page.Spec.Service; svc != nil {
+		svcPath := specPath.Child("service")
+		switch svc.Type {
+		case "", corev1.ServiceTypeClusterIP, corev1.ServiceTypeNodePort, corev1.ServiceTypeLoadBalancer:
+		default:
+			errs = append(errs, field.NotSupported(svcPath.Child("type"), svc.Type,
+				[]corev1.ServiceType{corev1.ServiceTypeClusterIP, corev1.ServiceTypeNodePort, corev1.ServiceTypeLoadBalancer}))
+		}
+		if svc.Port != 0 {
+			for _, msg := range validation.IsValidPortNum(int(svc.Port)) {
+				errs = append(errs, field.Invalid(svcPath.Child("port"), svc.Port, msg))
+			}
+		}
+	}
+
+	if ing := page.Spec.Ingress; ing != nil {
+		ingPath := specPath.Child("ingress")
+		if ing.Host == "" {
+			errs = append(errs, field.Required(ingPath.Child("host"), ""))
+		} else if strings.HasPrefix(ing.Host, "*.") {
+			for _, msg := range validation.IsWildcardDNS1123Subdomain(ing.Host) {
+				errs = append(errs, field.Invalid(ingPath.Child("host"), ing.Host, msg))
+			}
+		} else {
+			for _, msg := range validation.IsDNS1123Subdomain(ing.Host) {
+				errs = append(errs, field.Invalid(ingPath.Child("host"), ing.Host, msg))
+			}
+		}
+		if ing.Path != "" && !strings.HasPrefix(ing.Path, "/") {
+			errs = append(errs, field.Invalid(ingPath.Child("path"), ing.Path, "must be an absolute path"))
+		}
+		if ing.TLSSecretName != "" {
+			for _, msg := range validation.IsDNS1123Subdomain(ing.TLSSecretName) {
+				errs = append(errs, field.Invalid(ingPath.Child("tlsSecretName"), ing.TLSSecretName, msg))
+			}
+		}
+	}
+
+	if pdb := page.Spec.DisruptionBudget; pdb != nil {
+		pdbPath := specPath.Child("disruptionBudget")
+		if pdb.MinAvailable != nil && pdb.MaxUnavailable != nil {
+			errs = append(errs, field.Forbidden(pdbPath.Child("maxUnavailable"), "minAvailable and maxUnavailable are mutually exclusive"))
+		}
+		errs = append(errs, validateIntOrPercent(pdb.MinAvailable, pdbPath.Child("minAvailable"))...)
+		errs = append(errs, validateIntOrPercent(pdb.MaxUnavailable, pdbPath.Child("maxUnavailable"))...)
+	}
+
+	return errs
+}
+
+// validateIntOrPercent checks v is a non-negative number or a percentage between 0% and 100%.
+func validateIntOrPercent(v *intstr.IntOrString, fldPath *field.Path) field.ErrorList {
+	if v == nil {
+		return nil
+	}
+	if v.Type == intstr.String {
+		p, err := strconv.Atoi(strings.TrimSuffix(v.StrVal, "%"))
+		if err != nil || !strings.HasSuffix(v.StrVal, "%") || p < 0 || p > 100 {
+			return field.ErrorList{field.Invalid(fldPath, v.StrVal, "must be a percentage between 0% and 100%")}
+		}
+	} else if v.IntVal < 0 {
+		return field.ErrorList{field.Invalid(fldPath, v.IntVal, "must be greater than or equal to 0")}
+	}
+	return nil
+}
+
+func validateRolloutStrategy(page *frontendv1beta1.Frontend, rsPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	rs := page.Spec.RolloutStrategy
+	switch rs.Type {
+	case "", frontendv1beta1.RolloutRollingUpdate:
+		return nil
+	case frontendv1beta1.RolloutBlueGreen, frontendv1beta1.RolloutCanary:
+	default:
+		return append(errs, field.NotSupported(rsPath.Child("type"), rs.Type,
+			[]frontendv1beta1.RolloutStrategyType{frontendv1beta1.RolloutRollingUpdate, frontendv1beta1.RolloutBlueGreen, frontendv1beta1.RolloutCanary}))
+	}
+
+	// The strategies roll out content revisions, which in-place updates do not have
+	if page.Spec.ContentUpdatePolicy == frontendv1beta1.ContentUpdateInPlace {
+		errs = append(errs, field.Invalid(field.NewPath("spec", "contentUpdatePolicy"), page.Spec.ContentUpdatePolicy,
+			fmt.Sprintf("not supported with the %s rollout strategy", rs.Type)))
+	}
+	if rs.Type != frontendv1beta1.RolloutCanary {
+		return errs
+	}
+
+	canaryPath := rsPath.Child("canary")
+	if rs.Canary == nil || len(rs.Canary.Steps) == 0 {
+		return append(errs, field.Required(canaryPath.Child("steps"), "at least one step is required for the Canary strategy"))
+	}
+	for i, weight := range rs.Canary.Steps {
+		switch {
+		case weight < 1 || weight > 99:
+			errs = append(errs, field.Invalid(canaryPath.Child("steps").Index(i), weight, "must be between 1 and 99"))
+		case i > 0 && weight <= rs.Canary.Steps[i-1]:
+			errs = append(errs, field.Invalid(canaryPath.Child("steps").Index(i), weight, "must be greater than the previous step"))
+		}
+	}
+	if d := rs.Canary.StepDuration; d != nil && d.Duration < 0 {
+		errs = append(errs, field.Invalid(canaryPath.Child("stepDuration"), d.Duration.String(), "must not be negative"))
+	}
+	return errs
+}
+
+// AddFrontendWebhook registers the defaulting and validating webhooks for Frontends
+// on the webhook server of the manager.
+func AddFrontendWebhook(mgr manager.Manager) error {
+	w := &FrontendWebhook{}
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&frontendv1beta1.Frontend{}).
+		WithDefaulter(w).
+		WithValidator(w).
+		Complete()
+}
@@ -0,0 +1,141 @@
+package ctrl
+
+import (
+	context "context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	frontendv1alpha1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1alpha1"
+	frontendv1beta1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
+	testutil "github.com/oleksandr-san/k8s-controller/pkg/testutil"
+)
+
+func TestDefaultFrontend(t *testing.T) {
+	page := &frontendv1beta1.Frontend{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-page", Namespace: "default"},
+		Spec: frontendv1beta1.FrontendSpec{
+			Autoscaling: &frontendv1beta1.AutoscalingSpec{MaxReplicas: 5},
+			Service:     &frontendv1beta1.ServiceSpec{},
+			Ingress:     &frontendv1beta1.IngressSpec{Host: "www.example.com"},
+		},
+	}
+
+	require.NoError(t, (&FrontendWebhook{}).Default(context.Background(), page))
+	require.Equal(t, defaultImage, page.Spec.Image)
+	require.Equal(t, int32(1), *page.Spec.Replicas)
+	require.Equal(t, frontendv1beta1.ContentUpdateRollout, page.Spec.ContentUpdatePolicy)
+	require.Equal(t, "/data", page.Spec.MountPath)
+	require.Equal(t, corev1.ServiceTypeClusterIP, page.Spec.Service.Type)
+	require.Equal(t, int32(80), page.Spec.Service.Port)
+	require.Equal(t, "/", page.Spec.Ingress.Path)
+	require.Equal(t, int32(1), *page.Spec.Autoscaling.MinReplicas)
+	require.Equal(t, int32(10), *page.Spec.RevisionHistoryLimit)
+	require.Empty(t, validateFrontend(page))
+}
+
+func TestValidateFrontend(t *testing.T) {
+	page := &frontendv1beta1.Frontend{
+		ObjectMeta: metav1.ObjectMeta{Name: "Test.Page", Namespace: "default"},
+		Spec: frontendv1beta1.FrontendSpec{
+			Replicas:             int32Ptr(-3),
+			ContentUpdatePolicy:  "Sometimes",
+			RevisionHistoryLimit: int32Ptr(-1),
+			RollbackTo:           "yesterday",
+			Autoscaling:          &frontendv1beta1.AutoscalingSpec{MinReplicas: int32Ptr(3), MaxReplicas: 2, TargetCPUUtilizationPercentage: int32Ptr(0)},
+			PodTemplate:          &frontendv1beta1.PodTemplateSpec{Labels: map[string]string{"app": "other"}},
+			RolloutStrategy: &frontendv1beta1.RolloutStrategy{
+				Type:   frontendv1beta1.RolloutCanary,
+				Canary: &frontendv1beta1.CanaryStrategy{Steps: []int32{50, 20}},
+			},
+			Service: &frontendv1beta1.ServiceSpec{Type: corev1.ServiceTypeExternalName, Port: 70000},
+			Ingress: &frontendv1beta1.IngressSpec{Host: "not a host", Path: "relative"},
+			DisruptionBudget: &frontendv1beta1.DisruptionBudgetSpec{
+				MinAvailable:   ptrTo(intstr.FromString("150%")),
+				MaxUnavailable: ptrTo(intstr.FromInt32(1)),
+			},
+		},
+	}
+
+	_, err := (&FrontendWebhook{}).ValidateCreate(context.Background(), page)
+	require.True(t, apierrors.IsInvalid(err), "expected an Invalid error, got %v", err)
+
+	var fields []string
+	for _, e := range validateFrontend(page) {
+		fields = append(fields, e.Field)
+	}
+	require.ElementsMatch(t, []string{
+		"metadata.name",
+		"spec.image",
+		"spec.replicas",
+		"spec.contentUpdatePolicy",
+		"spec.revisionHistoryLimit",
+		"spec.rollbackTo",
+		"spec.autoscaling.maxReplicas",
+		"spec.autoscaling.targetCPUUtilizationPercentage",
+		"spec.podTemplate.labels[app]",
+		"spec.rolloutStrategy.canary.steps[1]",
+		"spec.service.type",
+		"spec.service.port",
+		"spec.ingress.host",
+		"spec.ingress.path",
+		"spec.disruptionBudget.minAvailable",
+		"spec.disruptionBudget.maxUnavailable",
+	}, fields)
+
+	page = testFrontend()
+	page.Name = "shop-canary"
+	errs := validateFrontend(page)
+	require.Len(t, errs, 1)
+	require.Equal(t, "metadata.name", errs[0].Field)
+	require.Contains(t, errs[0].Detail, "must not end with -canary")
+}
+
+func TestFrontendWebhook_Admission(t *testing.T) {
+	mgr, k8sClient, _, cleanup := testutil.StartTestManagerWithWebhooks(t)
+	defer cleanup()
+
+	require.NoError(t, AddFrontendWebhook(mgr))
+	check := mgr.GetWebhookServer().StartedChecker()
+	require.Eventually(t, func() bool {
+		return check(nil) == nil
+	}, 10*time.Second, 100*time.Millisecond, "webhook server should start")
+
+	ctx := context.Background()
+
+	// v1alpha1 objects are converted and defaulted
+	page := &frontendv1alpha1.Frontend{
+		ObjectMeta: metav1.ObjectMeta{Name: "defaulted-page", Namespace: "default"},
+		Spec:       frontendv1alpha1.FrontendSpec{Contents: "hello world", Replicas: 2},
+	}
+	require.NoError(t, k8sClient.Create(ctx, page))
+
+	var got frontendv1beta1.Frontend
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(page), &got))
+	require.Equal(t, defaultImage, got.Spec.Image)
+	require.Equal(t, int32(2), *got.Spec.Replicas)
+	require.Equal(t, "hello world", got.Spec.Files["contents"].Content)
+	require.Equal(t, frontendv1beta1.ContentUpdateRollout, got.Spec.ContentUpdatePolicy)
+
+	var gotAlpha frontendv1alpha1.Frontend
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(page), &gotAlpha))
+	require.Equal(t, "hello world", gotAlpha.Spec.Contents)
+	require.Empty(t, gotAlpha.Annotations)
+
+	// Invalid files are rejected with field-level errors
+	invalid := &frontendv1beta1.Frontend{
+		ObjectMeta: metav1.ObjectMeta{Name: "invalid-page", Namespace: "default"},
+		Spec: frontendv1beta1.FrontendSpec{
+			Files: map[string]frontendv1beta1.FrontendFile{"bad/key": {Content: "x"}},
+		},
+	}
+	err := k8sClient.Create(ctx, invalid)
+	require.True(t, apierrors.IsInvalid(err), "expected an Invalid error, got %v", err)
+	require.Contains(t, err.Error(), "spec.files[bad/key]")
+}
@@ -0,0 +1,191 @@
+package ctrl
+
+import (
+	context "context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Registry looks up the tags and digests of container images. Repositories are normalized
+// image names without tag, like docker.io/library/nginx.
+type Registry interface {
+	// Tags returns the tags of the repository.
+	Tags(ctx context.Context, repository string) ([]string, error)
+	// Digest returns the digest of the manifest the tag of the repository points to.
+	Digest(ctx context.Context, repository, tag string) (string, error)
+}
+
+// manifestMediaTypes are accepted when resolving digests, so that multi-arch images resolve
+// to the digest of their index.
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// HTTPRegistry is a Registry using the OCI distribution API of public registries, with
+// anonymous bearer tokens where the registry requires them.
+type HTTPRegistry struct {
+	Client *http.Client
+}
+
+func (r *HTTPRegistry) Tags(ctx context.Context, repository string) ([]string, error) {
+	host, name := registryHost(repository)
+	next := fmt.Sprintf("https://%s/v2/%s/tags/list", host, name)
+	var tags []string
+	for next != "" {
+		resp, err := r.do(ctx, http.MethodGet, next, nil)
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode tags of %s: %w", repository, err)
+		}
+		tags = append(tags, page.Tags...)
+		next, err = nextPage(resp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return tags, nil
+}
+
+func (r *HTTPRegistry) Digest(ctx context.Context, repository, tag string) (string, error) {
+	host, name := registryHost(repository)
+	resp, err := r.do(ctx, http.MethodHead, fmt.Sprintf("https://%s/v2/%s/manifests/%s", host, name, tag),
+		http.Header{"Accept": {strings.Join(manifestMediaTypes, ", ")}})
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", fmt.Errorf("registry returned no digest for %s:%s", repository, tag)
+	}
+	return digest, nil
+}
+
+// do sends a request to the registry, authenticating with an anonymous token if challenged.
+func (r *HTTPRegistry) do(ctx context.Context, method, target string, header http.Header) (*http.Response, error) {
+	send := func(token string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, target, nil)
+		if err != nil {
+			return nil, err
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		return r.client().Do(req)
+	}
+
+	resp, err := send("")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+		token, err := r.token(ctx, resp.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			return nil, err
+		}
+		if resp, err = send(token); err != nil {
+			return nil, err
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s: %s", method, target, resp.Status)
+	}
+	return resp, nil
+}
+
+// token requests an anonymous token for the bearer challenge of the registry.
+func (r *HTTPRegistry) token(ctx context.Context, challenge string) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", fmt.Errorf("unsupported registry authentication %q", challenge)
+	}
+	values := url.Values{}
+	var realm string
+	for _, param := range strings.Split(params, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		value = strings.Trim(value, `"`)
+		if key == "realm" {
+			realm = value
+		} else {
+			values.Set(key, value)
+		}
+	}
+	if realm == "" {
+		return "", fmt.Errorf("registry authentication %q has no realm", challenge)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm+"?"+values.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := r.client().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get registry token from %s: %s", realm, resp.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode registry token: %w", err)
+	}
+	if token.Token != "" {
+		return token.Token, nil
+	}
+	return token.AccessToken, nil
+}
+
+func (r *HTTPRegistry) client() *http.Client {
+	if r.Client != nil {
+		return r.Client
+	}
+	return http.DefaultClient
+}
+
+// nextPage returns the URL of the next page of a paginated response, "" on the last page.
+func nextPage(resp *http.Response) (string, error) {
+	link := resp.Header.Get("Link")
+	if link == "" {
+		return "", nil
+	}
+	start, end := strings.Index(link, "<"), strings.Index(link, ">")
+	if start < 0 || end < start || !strings.Contains(link[end:], `rel="next"`) {
+		return "", nil
+	}
+	next, err := resp.Request.URL.Parse(link[start+1 : end])
+	if err != nil {
+		return "", fmt.Errorf("invalid Link header %q: %w", link, err)
+	}
+	return next.String(), nil
+}
+
+// registryHost splits a normalized repository into the host serving its API and its name.
+func registryHost(repository string) (host, name string) {
+	host, name, _ = strings.Cut(repository, "/")
+	if host == "docker.io" {
+		host = "registry-1.docker.io"
+	}
+	return host, name
+}
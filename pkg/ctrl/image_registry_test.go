@@ -0,0 +1,66 @@
+package ctrl
+
+import (
+	context "context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPRegistry(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/token" {
+			require.Equal(t, "repository:app:pull", req.URL.Query().Get("scope"))
+			fmt.Fprint(w, `{"token": "anonymous"}`)
+			return
+		}
+		if req.Header.Get("Authorization") != "Bearer anonymous" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="repository:app:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case req.URL.Path == "/v2/app/tags/list" && req.URL.Query().Get("last") == "":
+			w.Header().Set("Link", `</v2/app/tags/list?n=2&last=v1.1.0>; rel="next"`)
+			fmt.Fprint(w, `{"name": "app", "tags": ["v1.0.0", "v1.1.0"]}`)
+		case req.URL.Path == "/v2/app/tags/list":
+			fmt.Fprint(w, `{"name": "app", "tags": ["v1.2.0"]}`)
+		case req.URL.Path == "/v2/app/manifests/v1.2.0" && req.Method == http.MethodHead:
+			require.Contains(t, req.Header.Get("Accept"), "application/vnd.oci.image.index.v1+json")
+			w.Header().Set("Docker-Content-Digest", "sha256:cccc")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	registry := &HTTPRegistry{Client: server.Client()}
+	repository := strings.TrimPrefix(server.URL, "https://") + "/app"
+	ctx := context.Background()
+
+	tags, err := registry.Tags(ctx, repository)
+	require.NoError(t, err)
+	require.Equal(t, []string{"v1.0.0", "v1.1.0", "v1.2.0"}, tags)
+
+	digest, err := registry.Digest(ctx, repository, "v1.2.0")
+	require.NoError(t, err)
+	require.Equal(t, "sha256:cccc", digest)
+
+	_, err = registry.Digest(ctx, repository, "v9.9.9")
+	require.ErrorContains(t, err, "404 Not Found")
+}
+
+func TestRegistryHost(t *testing.T) {
+	host, name := registryHost("docker.io/library/nginx")
+	require.Equal(t, "registry-1.docker.io", host)
+	require.Equal(t, "library/nginx", name)
+
+	host, name = registryHost("ghcr.io/org/app")
+	require.Equal(t, "ghcr.io", host)
+	require.Equal(t, "org/app", name)
+}
@@ -0,0 +1,332 @@
+package ctrl
+
+import (
+	context "context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blang/semver/v4"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	frontendv1beta1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
+)
+
+const (
+	// imagePolicyAnnotation opts a Deployment or Frontend into image updates. It is either
+	// "semver:<range>", to follow the highest tag in a range like ">=1.25.0 <2.0.0", or
+	// "digest", to pin the current tag to the digest it points to.
+	imagePolicyAnnotation = "image.oleksandr-san.io/policy"
+	// imageContainersAnnotation limits the updates of a Deployment to a comma-separated list of
+	// containers. All containers are updated if unset.
+	imageContainersAnnotation = "image.oleksandr-san.io/containers"
+	// lastImageUpdateAnnotation records the last automated image change.
+	lastImageUpdateAnnotation = "image.oleksandr-san.io/last-update"
+	// imageUpdaterFieldManager is the field manager of the updated images.
+	imageUpdaterFieldManager = "image-updater"
+	// defaultImageUpdateInterval is how often the registry is checked for new images.
+	defaultImageUpdateInterval = 5 * time.Minute
+)
+
+// Reasons of the events recorded for image updates.
+const (
+	eventReasonImageUpdated       = "ImageUpdated"
+	eventReasonInvalidImagePolicy = "InvalidImagePolicy"
+)
+
+// imageRef is a container image reference as written, split into its parts.
+type imageRef struct {
+	name   string
+	tag    string
+	digest string
+}
+
+func parseImageRef(image string) imageRef {
+	name, digest, _ := strings.Cut(image, "@")
+	ref := imageRef{name: name, digest: digest}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		ref.name, ref.tag = name[:i], name[i+1:]
+	}
+	return ref
+}
+
+func (r imageRef) String() string {
+	image := r.name
+	if r.tag != "" {
+		image += ":" + r.tag
+	}
+	if r.digest != "" {
+		image += "@" + r.digest
+	}
+	return image
+}
+
+// imagePolicy selects the image a container should run.
+type imagePolicy struct {
+	// versions is the semver range of the tags to follow, nil for digest pinning.
+	versions semver.Range
+}
+
+func parseImagePolicy(value string) (imagePolicy, error) {
+	if value == "digest" {
+		return imagePolicy{}, nil
+	}
+	if expr, ok := strings.CutPrefix(value, "semver:"); ok {
+		versions, err := semver.ParseRange(strings.TrimSpace(expr))
+		if err != nil {
+			return imagePolicy{}, fmt.Errorf("invalid %s annotation %q: %w", imagePolicyAnnotation, value, err)
+		}
+		return imagePolicy{versions: versions}, nil
+	}
+	return imagePolicy{}, fmt.Errorf("invalid %s annotation %q: must be digest or semver:<range>", imagePolicyAnnotation, value)
+}
+
+// imageUpdater resolves the images selected by image policies, caching the tags of each
+// repository for one update interval.
+type imageUpdater struct {
+	registry Registry
+	interval time.Duration
+	now      func() time.Time
+
+	mu   sync.Mutex
+	tags map[string]cachedTags
+}
+
+type cachedTags struct {
+	tags    []string
+	fetched time.Time
+}
+
+func newImageUpdater(registry Registry, interval time.Duration) *imageUpdater {
+	return &imageUpdater{
+		registry: registry,
+		interval: interval,
+		now:      time.Now,
+		tags:     map[string]cachedTags{},
+	}
+}
+
+// resolve returns the image policy selects for the container running image, which is image
+// itself if it is up to date.
+func (u *imageUpdater) resolve(ctx context.Context, image string, policy imagePolicy) (string, error) {
+	ref := parseImageRef(image)
+	repository := normalizeImage(ref.name)
+	if ref.tag == "" {
+		ref.tag = "latest"
+	}
+
+	if policy.versions == nil {
+		digest, err := u.registry.Digest(ctx, repository, ref.tag)
+		if err != nil {
+			return "", err
+		}
+		ref.digest = digest
+		return ref.String(), nil
+	}
+
+	tags, err := u.repositoryTags(ctx, repository)
+	if err != nil {
+		return "", err
+	}
+	// Pre-releases and tags that are not versions, like latest or alpine, are never selected
+	bestTag := ""
+	var best semver.Version
+	for _, tag := range append([]string{ref.tag}, tags...) {
+		version, err := semver.ParseTolerant(tag)
+		if err != nil || len(version.Pre) > 0 || !policy.versions(version) {
+			continue
+		}
+		if bestTag == "" || version.GT(best) {
+			bestTag, best = tag, version
+		}
+	}
+	if bestTag == "" || bestTag == ref.tag {
+		return image, nil
+	}
+	ref.tag, ref.digest = bestTag, ""
+	return ref.String(), nil
+}
+
+func (u *imageUpdater) repositoryTags(ctx context.Context, repository string) ([]string, error) {
+	u.mu.Lock()
+	cached, ok := u.tags[repository]
+	u.mu.Unlock()
+	if ok && u.now().Sub(cached.fetched) < u.interval {
+		return cached.tags, nil
+	}
+
+	tags, err := u.registry.Tags(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	u.mu.Lock()
+	u.tags[repository] = cachedTags{tags: tags, fetched: u.now()}
+	u.mu.Unlock()
+	return tags, nil
+}
+
+// auditEntry returns the value of the last update annotation for the changes.
+func (u *imageUpdater) auditEntry(changes []string) string {
+	return fmt.Sprintf("%s %s", u.now().UTC().Format(time.RFC3339), strings.Join(changes, ", "))
+}
+
+// ImageUpdateOptions configures the image update controllers.
+type ImageUpdateOptions struct {
+	// Registry looks up image tags and digests, the public registries if nil.
+	Registry Registry
+	// Interval is how often the images are checked, 5 minutes if 0.
+	Interval time.Duration
+}
+
+// DeploymentImageReconciler updates the container images of Deployments with an image policy.
+type DeploymentImageReconciler struct {
+	client.Client
+	Recorder record.EventRecorder
+	updater  *imageUpdater
+}
+
+func (r *DeploymentImageReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	dep := &appsv1.Deployment{}
+	if err := r.Get(ctx, req.NamespacedName, dep); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	value, ok := dep.Annotations[imagePolicyAnnotation]
+	if !ok || !dep.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+	policy, err := parseImagePolicy(value)
+	if err != nil {
+		// Retrying will not help, wait for the annotation to change
+		r.Recorder.Event(dep, corev1.EventTypeWarning, eventReasonInvalidImagePolicy, err.Error())
+		return ctrl.Result{}, nil
+	}
+
+	var selected []string
+	if names := dep.Annotations[imageContainersAnnotation]; names != "" {
+		for _, name := range strings.Split(names, ",") {
+			selected = append(selected, strings.TrimSpace(name))
+		}
+	}
+	patch := client.StrategicMergeFrom(dep.DeepCopy())
+	var changes []string
+	for i := range dep.Spec.Template.Spec.Containers {
+		container := &dep.Spec.Template.Spec.Containers[i]
+		if len(selected) > 0 && !slices.Contains(selected, container.Name) {
+			continue
+		}
+		image, err := r.updater.resolve(ctx, container.Image, policy)
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to resolve image of container %s: %w", container.Name, err)
+		}
+		if image != container.Image {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", container.Name, container.Image, image))
+			container.Image = image
+		}
+	}
+	result := ctrl.Result{RequeueAfter: r.updater.interval}
+	if len(changes) == 0 {
+		return result, nil
+	}
+
+	dep.Annotations[lastImageUpdateAnnotation] = r.updater.auditEntry(changes)
+	if err := r.Patch(ctx, dep, patch, client.FieldOwner(imageUpdaterFieldManager)); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	log.Info().Strs("changes", changes).Msgf("Updated images of Deployment: %s/%s", dep.Namespace, dep.Name)
+	for _, change := range changes {
+		r.Recorder.Eventf(dep, corev1.EventTypeNormal, eventReasonImageUpdated, "Updated image of container %s", change)
+	}
+	return result, nil
+}
+
+// FrontendImageReconciler updates spec.image of Frontends with an image policy.
+type FrontendImageReconciler struct {
+	client.Client
+	Recorder record.EventRecorder
+	updater  *imageUpdater
+}
+
+func (r *FrontendImageReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	page := &frontendv1beta1.Frontend{}
+	if err := r.Get(ctx, req.NamespacedName, page); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	value, ok := page.Annotations[imagePolicyAnnotation]
+	if !ok || !page.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+	policy, err := parseImagePolicy(value)
+	if err != nil {
+		// Retrying will not help, wait for the annotation to change
+		r.Recorder.Event(page, corev1.EventTypeWarning, eventReasonInvalidImagePolicy, err.Error())
+		return ctrl.Result{}, nil
+	}
+
+	current := page.Spec.Image
+	if current == "" {
+		current = defaultImage
+	}
+	image, err := r.updater.resolve(ctx, current, policy)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to resolve image: %w", err)
+	}
+	result := ctrl.Result{RequeueAfter: r.updater.interval}
+	if image == current {
+		return result, nil
+	}
+
+	change := fmt.Sprintf("%s -> %s", current, image)
+	patch := client.MergeFrom(page.DeepCopy())
+	page.Spec.Image = image
+	page.Annotations[lastImageUpdateAnnotation] = r.updater.auditEntry([]string{change})
+	if err := r.Patch(ctx, page, patch, client.FieldOwner(imageUpdaterFieldManager)); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	log.Info().Msgf("Updated image of Frontend: %s/%s (%s)", page.Namespace, page.Name, change)
+	r.Recorder.Eventf(page, corev1.EventTypeNormal, eventReasonImageUpdated, "Updated image %s", change)
+	return result, nil
+}
+
+func init() {
+	RegisterController(Controller{
+		Name: "imageupdate",
+		Setup: func(mgr manager.Manager, config *viper.Viper) error {
+			return AddImageUpdateController(mgr, ImageUpdateOptions{Interval: config.GetDuration("interval")})
+		},
+	})
+}
+
+func AddImageUpdateController(mgr manager.Manager, opts ImageUpdateOptions) error {
+	if opts.Registry == nil {
+		opts.Registry = &HTTPRegistry{Client: &http.Client{Timeout: 30 * time.Second}}
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = defaultImageUpdateInterval
+	}
+	updater := newImageUpdater(opts.Registry, opts.Interval)
+	recorder := newDedupRecorder(mgr.GetEventRecorderFor(imageUpdaterFieldManager))
+	changed := builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{}))
+
+	if err := ctrl.NewControllerManagedBy(mgr).
+		Named("image-update-deployment").
+		For(&appsv1.Deployment{}, changed).
+		Complete(&DeploymentImageReconciler{Client: mgr.GetClient(), Recorder: recorder, updater: updater}); err != nil {
+		return err
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("image-update-frontend").
+		For(&frontendv1beta1.Frontend{}, changed).
+		Complete(&FrontendImageReconciler{Client: mgr.GetClient(), Recorder: recorder, updater: updater})
+}
@@ -0,0 +1,212 @@
+package ctrl
+
+import (
+	context "context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	frontendv1beta1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
+)
+
+// fakeRegistry is an in-process Registry serving fixed tags and digests.
+type fakeRegistry struct {
+	tags    map[string][]string
+	digests map[string]string
+	calls   int
+}
+
+func (r *fakeRegistry) Tags(_ context.Context, repository string) ([]string, error) {
+	r.calls++
+	tags, ok := r.tags[repository]
+	if !ok {
+		return nil, fmt.Errorf("repository %s not found", repository)
+	}
+	return tags, nil
+}
+
+func (r *fakeRegistry) Digest(_ context.Context, repository, tag string) (string, error) {
+	r.calls++
+	digest, ok := r.digests[repository+":"+tag]
+	if !ok {
+		return "", fmt.Errorf("manifest %s:%s not found", repository, tag)
+	}
+	return digest, nil
+}
+
+func newFakeRegistry() *fakeRegistry {
+	return &fakeRegistry{
+		tags: map[string][]string{
+			"docker.io/library/nginx":  {"latest", "alpine", "1.25.0", "1.25.3", "1.26.0", "1.27.0-rc1", "2.0.0", "1.25.4-alpine"},
+			"registry.example.com/app": {"v1.0.0", "v1.1.0", "v1.2"},
+		},
+		digests: map[string]string{
+			"docker.io/library/nginx:1.25.0": "sha256:aaaa",
+			"docker.io/library/nginx:latest": "sha256:bbbb",
+		},
+	}
+}
+
+func TestParseImageRef(t *testing.T) {
+	for image, want := range map[string]imageRef{
+		"nginx":                               {name: "nginx"},
+		"nginx:1.25":                          {name: "nginx", tag: "1.25"},
+		"registry.example.com:5000/app":       {name: "registry.example.com:5000/app"},
+		"registry.example.com:5000/app:v1":    {name: "registry.example.com:5000/app", tag: "v1"},
+		"nginx:1.25@sha256:aaaa":              {name: "nginx", tag: "1.25", digest: "sha256:aaaa"},
+		"registry.example.com/app@sha256:bbb": {name: "registry.example.com/app", digest: "sha256:bbb"},
+	} {
+		ref := parseImageRef(image)
+		require.Equal(t, want, ref, image)
+		require.Equal(t, image, ref.String())
+	}
+}
+
+func TestParseImagePolicy(t *testing.T) {
+	policy, err := parseImagePolicy("digest")
+	require.NoError(t, err)
+	require.Nil(t, policy.versions)
+
+	policy, err = parseImagePolicy("semver: >=1.25.0 <2.0.0")
+	require.NoError(t, err)
+	require.NotNil(t, policy.versions)
+
+	_, err = parseImagePolicy("semver:latest")
+	require.ErrorContains(t, err, "invalid image.oleksandr-san.io/policy annotation")
+	_, err = parseImagePolicy("newest")
+	require.ErrorContains(t, err, "must be digest or semver:<range>")
+}
+
+func TestImageUpdaterResolve(t *testing.T) {
+	updater := newImageUpdater(newFakeRegistry(), time.Minute)
+
+	for _, tc := range []struct {
+		image  string
+		policy string
+		want   string
+	}{
+		{image: "nginx:1.25.0", policy: "semver:>=1.25.0 <2.0.0", want: "nginx:1.26.0"},
+		{image: "nginx:1.25.0", policy: "semver:>=1.25.0 <1.26.0", want: "nginx:1.25.3"},
+		{image: "nginx:1.26.0", policy: "semver:>=1.25.0 <2.0.0", want: "nginx:1.26.0"},
+		{image: "nginx:alpine", policy: "semver:>=1.0.0", want: "nginx:2.0.0"},
+		{image: "nginx:1.25.0@sha256:aaaa", policy: "semver:>=1.25.0 <1.26.0", want: "nginx:1.25.3"},
+		{image: "nginx:1.25.0", policy: "semver:>=3.0.0", want: "nginx:1.25.0"},
+		{image: "registry.example.com/app:v1.0.0", policy: "semver:<2.0.0", want: "registry.example.com/app:v1.2"},
+		{image: "nginx:1.25.0", policy: "digest", want: "nginx:1.25.0@sha256:aaaa"},
+		{image: "nginx", policy: "digest", want: "nginx:latest@sha256:bbbb"},
+	} {
+		t.Run(tc.image+" "+tc.policy, func(t *testing.T) {
+			policy, err := parseImagePolicy(tc.policy)
+			require.NoError(t, err)
+			got, err := updater.resolve(context.Background(), tc.image, policy)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+
+	_, err := updater.resolve(context.Background(), "missing:1.0.0", imagePolicy{})
+	require.ErrorContains(t, err, "not found")
+}
+
+func TestImageUpdaterCachesTags(t *testing.T) {
+	registry := newFakeRegistry()
+	updater := newImageUpdater(registry, time.Minute)
+	now := time.Now()
+	updater.now = func() time.Time { return now }
+	policy, err := parseImagePolicy("semver:>=1.0.0")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	for range 3 {
+		_, err := updater.resolve(ctx, "nginx:1.25.0", policy)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 1, registry.calls, "tags should be fetched once per interval")
+
+	now = now.Add(time.Minute)
+	_, err = updater.resolve(ctx, "nginx:1.25.0", policy)
+	require.NoError(t, err)
+	require.Equal(t, 2, registry.calls)
+}
+
+func TestDeploymentImageReconciler(t *testing.T) {
+	dep := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web",
+			Namespace: "default",
+			Annotations: map[string]string{
+				imagePolicyAnnotation:     "semver:>=1.25.0 <1.26.0",
+				imageContainersAnnotation: "nginx",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{Name: "nginx", Image: "nginx:1.25.0"},
+				{Name: "sidecar", Image: "nginx:1.25.0"},
+			},
+		}}},
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := &DeploymentImageReconciler{
+		Client:   newFakeReconciler(t, dep).Client,
+		Recorder: recorder,
+		updater:  newImageUpdater(newFakeRegistry(), time.Minute),
+	}
+
+	ctx := context.Background()
+	key := client.ObjectKeyFromObject(dep)
+	result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, result.RequeueAfter, "the registry should be polled")
+
+	var got appsv1.Deployment
+	require.NoError(t, r.Get(ctx, key, &got))
+	require.Equal(t, "nginx:1.25.3", got.Spec.Template.Spec.Containers[0].Image)
+	require.Equal(t, "nginx:1.25.0", got.Spec.Template.Spec.Containers[1].Image, "only selected containers should be updated")
+	require.Contains(t, got.Annotations[lastImageUpdateAnnotation], "nginx: nginx:1.25.0 -> nginx:1.25.3")
+	require.Contains(t, <-recorder.Events, "Normal ImageUpdated Updated image of container nginx: nginx:1.25.0 -> nginx:1.25.3")
+
+	// Invalid policies are reported and not retried
+	got.Annotations[imagePolicyAnnotation] = "newest"
+	require.NoError(t, r.Update(ctx, &got))
+	result, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+	require.Zero(t, result.RequeueAfter)
+	require.Contains(t, <-recorder.Events, "Warning InvalidImagePolicy")
+}
+
+func TestFrontendImageReconciler(t *testing.T) {
+	page := testFrontend()
+	page.Spec.Image = "nginx:1.25.0"
+	page.Annotations = map[string]string{imagePolicyAnnotation: "digest"}
+	recorder := record.NewFakeRecorder(10)
+	r := &FrontendImageReconciler{
+		Client:   newFakeReconciler(t, page).Client,
+		Recorder: recorder,
+		updater:  newImageUpdater(newFakeRegistry(), time.Minute),
+	}
+
+	ctx := context.Background()
+	key := client.ObjectKeyFromObject(page)
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+
+	var got frontendv1beta1.Frontend
+	require.NoError(t, r.Get(ctx, key, &got))
+	require.Equal(t, "nginx:1.25.0@sha256:aaaa", got.Spec.Image)
+	require.Contains(t, got.Annotations[lastImageUpdateAnnotation], "nginx:1.25.0 -> nginx:1.25.0@sha256:aaaa")
+	require.Contains(t, <-recorder.Events, "Normal ImageUpdated")
+
+	// A pinned image is up to date until the tag moves
+	_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+	require.Empty(t, recorder.Events)
+}
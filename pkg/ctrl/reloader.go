@@ -0,0 +1,250 @@
+package ctrl
+
+import (
+	context "context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"maps"
+	"slices"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// reloadAnnotation opts a Deployment into reloads when set to "true" on the Deployment or
+	// on its pod template, which lets Frontends opt in through spec.podTemplate.annotations.
+	reloadAnnotation = "reload.oleksandr-san.io/enabled"
+	// configHashAnnotation records the hash of the ConfigMaps and Secrets referenced by the
+	// pods. Stamping it onto the pod template rolls the Deployment.
+	configHashAnnotation = "reload.oleksandr-san.io/config-hash"
+	// reloaderFieldManager is the field manager of the config hash annotations.
+	reloaderFieldManager = "reloader-controller"
+)
+
+// Field indexes of opted-in Deployments by the names of the objects referenced by their pods
+const (
+	reloadConfigMapIndex = ".spec.template.spec.configMapRefs"
+	reloadSecretIndex    = ".spec.template.spec.secretRefs"
+)
+
+// eventReasonReloaded is the reason of the events recorded when a Deployment is rolled.
+const eventReasonReloaded = "Reloaded"
+
+func reloadEnabled(dep *appsv1.Deployment) bool {
+	return dep.Annotations[reloadAnnotation] == "true" || dep.Spec.Template.Annotations[reloadAnnotation] == "true"
+}
+
+// podReferences returns the names of the ConfigMaps and Secrets referenced by the volumes,
+// envFrom and env of spec.
+func podReferences(spec *corev1.PodSpec) (configMaps, secrets sets.Set[string]) {
+	configMaps, secrets = sets.New[string](), sets.New[string]()
+	for _, volume := range spec.Volumes {
+		switch {
+		case volume.ConfigMap != nil:
+			configMaps.Insert(volume.ConfigMap.Name)
+		case volume.Secret != nil:
+			secrets.Insert(volume.Secret.SecretName)
+		case volume.Projected != nil:
+			for _, src := range volume.Projected.Sources {
+				if src.ConfigMap != nil {
+					configMaps.Insert(src.ConfigMap.Name)
+				}
+				if src.Secret != nil {
+					secrets.Insert(src.Secret.Name)
+				}
+			}
+		}
+	}
+	for _, container := range slices.Concat(spec.InitContainers, spec.Containers) {
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.ConfigMapRef != nil {
+				configMaps.Insert(envFrom.ConfigMapRef.Name)
+			}
+			if envFrom.SecretRef != nil {
+				secrets.Insert(envFrom.SecretRef.Name)
+			}
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if env.ValueFrom.ConfigMapKeyRef != nil {
+				configMaps.Insert(env.ValueFrom.ConfigMapKeyRef.Name)
+			}
+			if env.ValueFrom.SecretKeyRef != nil {
+				secrets.Insert(env.ValueFrom.SecretKeyRef.Name)
+			}
+		}
+	}
+	configMaps.Delete("")
+	secrets.Delete("")
+	return configMaps, secrets
+}
+
+// reloadIndexers extract the names of the objects referenced by the pods of opted-in Deployments.
+var reloadIndexers = map[string]client.IndexerFunc{
+	reloadConfigMapIndex: func(obj client.Object) []string {
+		dep := obj.(*appsv1.Deployment)
+		if !reloadEnabled(dep) {
+			return nil
+		}
+		configMaps, _ := podReferences(&dep.Spec.Template.Spec)
+		return sets.List(configMaps)
+	},
+	reloadSecretIndex: func(obj client.Object) []string {
+		dep := obj.(*appsv1.Deployment)
+		if !reloadEnabled(dep) {
+			return nil
+		}
+		_, secrets := podReferences(&dep.Spec.Template.Spec)
+		return sets.List(secrets)
+	},
+}
+
+// ReloaderReconciler rolls opted-in Deployments when the ConfigMaps and Secrets referenced by
+// their pods change.
+type ReloaderReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+func (r *ReloaderReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	dep := &appsv1.Deployment{}
+	if err := r.Get(ctx, req.NamespacedName, dep); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !dep.DeletionTimestamp.IsZero() || !reloadEnabled(dep) {
+		return ctrl.Result{}, nil
+	}
+
+	configHash, err := r.configHash(ctx, dep)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	previous := dep.Annotations[configHashAnnotation]
+	if configHash == previous {
+		return ctrl.Result{}, nil
+	}
+
+	// The first hash is only recorded, so that opting in does not roll the Deployment
+	patch := client.MergeFrom(dep.DeepCopy())
+	dep.Annotations = mergeMaps(dep.Annotations, map[string]string{configHashAnnotation: configHash})
+	reload := previous != ""
+	if reload {
+		dep.Spec.Template.Annotations = mergeMaps(dep.Spec.Template.Annotations, map[string]string{configHashAnnotation: configHash})
+	}
+	if err := r.Patch(ctx, dep, patch, client.FieldOwner(reloaderFieldManager)); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if reload {
+		log.Info().Msgf("Reloaded Deployment: %s/%s (config hash %s)", dep.Namespace, dep.Name, configHash)
+		r.Recorder.Eventf(dep, corev1.EventTypeNormal, eventReasonReloaded,
+			"Rolled out changes of referenced ConfigMaps and Secrets (config hash %s)", configHash)
+	}
+	return ctrl.Result{}, nil
+}
+
+// configHash returns a short hash of the data of the ConfigMaps and Secrets referenced by the
+// pods of dep. Missing objects are hashed too, so that creating them rolls the Deployment.
+func (r *ReloaderReconciler) configHash(ctx context.Context, dep *appsv1.Deployment) (string, error) {
+	configMaps, secrets := podReferences(&dep.Spec.Template.Spec)
+	h := sha256.New()
+	for _, name := range sets.List(configMaps) {
+		cm := &corev1.ConfigMap{}
+		if err := r.Get(ctx, client.ObjectKey{Namespace: dep.Namespace, Name: name}, cm); err != nil {
+			if !errors.IsNotFound(err) {
+				return "", err
+			}
+			fmt.Fprintf(h, "configmap/%s missing\n", name)
+			continue
+		}
+		fmt.Fprintf(h, "configmap/%s\n", name)
+		hashData(h, cm.Data)
+		hashData(h, cm.BinaryData)
+	}
+	for _, name := range sets.List(secrets) {
+		secret := &corev1.Secret{}
+		if err := r.Get(ctx, client.ObjectKey{Namespace: dep.Namespace, Name: name}, secret); err != nil {
+			if !errors.IsNotFound(err) {
+				return "", err
+			}
+			fmt.Fprintf(h, "secret/%s missing\n", name)
+			continue
+		}
+		fmt.Fprintf(h, "secret/%s\n", name)
+		hashData(h, secret.Data)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16], nil
+}
+
+func hashData[V string | []byte](h hash.Hash, data map[string]V) {
+	for _, k := range slices.Sorted(maps.Keys(data)) {
+		fmt.Fprintf(h, "%s=%x\n", k, data[k])
+	}
+}
+
+// deploymentsReferencing returns a handler mapping an object to the opted-in Deployments
+// referencing it through index.
+func (r *ReloaderReconciler) deploymentsReferencing(index string) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []ctrl.Request {
+		var deps appsv1.DeploymentList
+		if err := r.List(ctx, &deps, client.InNamespace(obj.GetNamespace()), client.MatchingFields{index: obj.GetName()}); err != nil {
+			log.Error().Err(err).Msgf("Failed to list Deployments referencing %s %s", obj.GetName(), obj.GetNamespace())
+			return nil
+		}
+
+		requests := make([]ctrl.Request, 0, len(deps.Items))
+		for _, dep := range deps.Items {
+			requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&dep)})
+		}
+		return requests
+	}
+}
+
+func init() {
+	RegisterController(Controller{
+		Name: "reloader",
+		Setup: func(mgr manager.Manager, _ *viper.Viper) error {
+			return AddReloaderController(mgr)
+		},
+	})
+}
+
+func AddReloaderController(mgr manager.Manager) error {
+	for index, indexer := range reloadIndexers {
+		if err := mgr.GetFieldIndexer().IndexField(context.Background(), &appsv1.Deployment{}, index, indexer); err != nil {
+			return err
+		}
+	}
+
+	r := &ReloaderReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: newDedupRecorder(mgr.GetEventRecorderFor(reloaderFieldManager)),
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("reloader").
+		For(&appsv1.Deployment{}, builder.WithPredicates(predicate.Or(
+			predicate.GenerationChangedPredicate{},
+			predicate.AnnotationChangedPredicate{},
+		))).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.deploymentsReferencing(reloadConfigMapIndex))).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.deploymentsReferencing(reloadSecretIndex))).
+		Complete(r)
+}
@@ -0,0 +1,118 @@
+package ctrl
+
+import (
+	context "context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func reloadedDeployment() *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "web",
+			Namespace:   "default",
+			Annotations: map[string]string{reloadAnnotation: "true"},
+		},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			Volumes: []corev1.Volume{
+				{Name: "config", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "web-config"}}}},
+				{Name: "tls", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "web-tls"}}},
+				{Name: "bundle", VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{Sources: []corev1.VolumeProjection{
+					{ConfigMap: &corev1.ConfigMapProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "ca-bundle"}}},
+				}}}},
+			},
+			InitContainers: []corev1.Container{{
+				Name:    "migrate",
+				EnvFrom: []corev1.EnvFromSource{{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "db"}}}},
+			}},
+			Containers: []corev1.Container{{
+				Name: "nginx",
+				Env: []corev1.EnvVar{{Name: "MODE", ValueFrom: &corev1.EnvVarSource{
+					ConfigMapKeyRef: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "web-env"}, Key: "mode"},
+				}}},
+			}},
+		}}},
+	}
+}
+
+func TestPodReferences(t *testing.T) {
+	configMaps, secrets := podReferences(&reloadedDeployment().Spec.Template.Spec)
+	require.Equal(t, sets.New("web-config", "ca-bundle", "web-env"), configMaps)
+	require.Equal(t, sets.New("web-tls", "db"), secrets)
+}
+
+func TestReloadIndexers(t *testing.T) {
+	dep := reloadedDeployment()
+	require.Equal(t, []string{"ca-bundle", "web-config", "web-env"}, reloadIndexers[reloadConfigMapIndex](dep))
+	require.Equal(t, []string{"db", "web-tls"}, reloadIndexers[reloadSecretIndex](dep))
+
+	// Deployments that did not opt in are not indexed
+	dep.Annotations = nil
+	require.Empty(t, reloadIndexers[reloadConfigMapIndex](dep))
+
+	// Frontends opt in through the annotations of their pod template
+	dep.Spec.Template.Annotations = map[string]string{reloadAnnotation: "true"}
+	require.NotEmpty(t, reloadIndexers[reloadConfigMapIndex](dep))
+}
+
+func TestReloaderReconciler(t *testing.T) {
+	dep := reloadedDeployment()
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-config", Namespace: dep.Namespace},
+		Data:       map[string]string{"nginx.conf": "worker_processes 1;"},
+	}
+	b := fake.NewClientBuilder().WithObjects(dep, cm)
+	for index, indexer := range reloadIndexers {
+		b = b.WithIndex(&appsv1.Deployment{}, index, indexer)
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := &ReloaderReconciler{Client: b.Build(), Recorder: recorder}
+
+	ctx := context.Background()
+	key := client.ObjectKeyFromObject(dep)
+	reconcileAndGet := func() *appsv1.Deployment {
+		t.Helper()
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		require.NoError(t, err)
+		got := &appsv1.Deployment{}
+		require.NoError(t, r.Get(ctx, key, got))
+		return got
+	}
+
+	got := reconcileAndGet()
+	initial := got.Annotations[configHashAnnotation]
+	require.NotEmpty(t, initial, "the config hash should be recorded")
+	require.Empty(t, got.Spec.Template.Annotations, "opting in should not roll the Deployment")
+
+	require.Equal(t, []ctrl.Request{{NamespacedName: key}}, r.deploymentsReferencing(reloadConfigMapIndex)(ctx, cm))
+
+	cm.Data["nginx.conf"] = "worker_processes 2;"
+	require.NoError(t, r.Update(ctx, cm))
+	got = reconcileAndGet()
+	changed := got.Annotations[configHashAnnotation]
+	require.NotEqual(t, initial, changed)
+	require.Equal(t, changed, got.Spec.Template.Annotations[configHashAnnotation], "changed data should roll the Deployment")
+	require.Contains(t, <-recorder.Events, "Normal Reloaded")
+
+	// Creating a referenced object that was missing rolls the Deployment too
+	require.NoError(t, r.Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-tls", Namespace: dep.Namespace},
+		Data:       map[string][]byte{"tls.crt": []byte("cert")},
+	}))
+	got = reconcileAndGet()
+	require.NotEqual(t, changed, got.Spec.Template.Annotations[configHashAnnotation])
+
+	// Unchanged data does not
+	rolled := got.Spec.Template.Annotations[configHashAnnotation]
+	got = reconcileAndGet()
+	require.Equal(t, rolled, got.Spec.Template.Annotations[configHashAnnotation])
+}
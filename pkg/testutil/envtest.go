@@ -9,6 +9,7 @@ import (
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 
 	frontendv1alpha1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1alpha1"
+	frontendv1beta1 "github.com/oleksandr-san/k8s-controller/pkg/apis/frontend/v1beta1"
 	"github.com/stretchr/testify/require"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -20,26 +21,48 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 )
 
 // StartTestManager sets up envtest, scheme, manager, and returns them with cleanup.
 func StartTestManager(t *testing.T) (mgr manager.Manager, k8sClient client.Client, restCfg *rest.Config, cleanup func()) {
+	t.Helper()
+	return startTestManager(t, envtest.WebhookInstallOptions{})
+}
+
+// StartTestManagerWithWebhooks is like StartTestManager, but also installs the webhook
+// configurations from config/webhook and serves them, including the CRD conversion
+// webhook, from the manager's webhook server.
+// Webhooks must be registered on the returned manager before use.
+func StartTestManagerWithWebhooks(t *testing.T) (mgr manager.Manager, k8sClient client.Client, restCfg *rest.Config, cleanup func()) {
+	t.Helper()
+	return startTestManager(t, envtest.WebhookInstallOptions{
+		Paths: []string{"../../config/webhook/"},
+	})
+}
+
+func startTestManager(t *testing.T, webhookOpts envtest.WebhookInstallOptions) (mgr manager.Manager, k8sClient client.Client, restCfg *rest.Config, cleanup func()) {
 	t.Helper()
 	testScheme := runtime.NewScheme()
 
 	// Add the core Kubernetes schemes
 	require.NoError(t, scheme.AddToScheme(testScheme))
 	require.NoError(t, frontendv1alpha1.AddToScheme(testScheme))
+	require.NoError(t, frontendv1beta1.AddToScheme(testScheme))
 	metav1.AddToGroupVersion(testScheme, frontendv1alpha1.SchemeGroupVersion)
+	metav1.AddToGroupVersion(testScheme, frontendv1beta1.SchemeGroupVersion)
 	require.NoError(t, apiextensionsv1.AddToScheme(testScheme))
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	// The scheme lets envtest point the CRD conversion at the webhook server, if installed
 	env := &envtest.Environment{
+		Scheme:                   testScheme,
 		CRDDirectoryPaths:        []string{"../../config/crd/"},
 		ErrorIfCRDPathMissing:    true,
 		AttachControlPlaneOutput: false,
+		WebhookInstallOptions:    webhookOpts,
 	}
 	var startErr = make(chan error)
 	var cfg *rest.Config
@@ -60,7 +83,15 @@ func StartTestManager(t *testing.T) (mgr manager.Manager, k8sClient client.Clien
 
 	require.NotNil(t, cfg)
 
-	mgr, err = manager.New(cfg, manager.Options{Scheme: testScheme, LeaderElection: false})
+	opts := manager.Options{Scheme: testScheme, LeaderElection: false}
+	if len(webhookOpts.Paths) > 0 {
+		opts.WebhookServer = webhook.NewServer(webhook.Options{
+			Host:    env.WebhookInstallOptions.LocalServingHost,
+			Port:    env.WebhookInstallOptions.LocalServingPort,
+			CertDir: env.WebhookInstallOptions.LocalServingCertDir,
+		})
+	}
+	mgr, err = manager.New(cfg, opts)
 	require.NoError(t, err)
 
 	ctx, cancel = context.WithCancel(context.Background())